@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"regexp"
+	"strings"
+	"unicode"
+
+	gql "github.com/arianito/gql/pkg"
+)
+
+// generate writes a struct per table with its TableName, a table constant and a struct of column
+// names so that Where, OrderBy and Columns calls break at compile time when a column is renamed.
+func generate(pkg string, tables []gql.TableInfo) ([]byte, error) {
+	var body bytes.Buffer
+	imports := map[string]bool{}
+	for _, table := range tables {
+		name := gql.Singular(goName(table.Name))
+		fields := make([]string, len(table.Columns))
+		for i, column := range table.Columns {
+			fields[i] = goName(column.Name)
+		}
+
+		fmt.Fprintf(&body, "const %sTable = %q\n\n", name, table.Name)
+		fmt.Fprintf(&body, "var %sColumns = struct {\n", name)
+		for _, field := range fields {
+			fmt.Fprintf(&body, "\t%s string\n", field)
+		}
+		body.WriteString("}{\n")
+		for i, column := range table.Columns {
+			fmt.Fprintf(&body, "\t%s: %q,\n", fields[i], column.Name)
+		}
+		body.WriteString("}\n\n")
+
+		fmt.Fprintf(&body, "type %s struct {\n", name)
+		for i, column := range table.Columns {
+			typ := goType(column)
+			if strings.HasPrefix(typ, "gql.") {
+				imports["gql \"github.com/arianito/gql/pkg\""] = true
+			} else if strings.HasPrefix(typ, "time.") {
+				imports["\"time\""] = true
+			}
+			fmt.Fprintf(&body, "\t%s %s `gql:\"%s\"`\n", fields[i], typ, tag(column, table.Columns))
+		}
+		body.WriteString("}\n\n")
+		fmt.Fprintf(&body, "func (%s) TableName() string {\n\treturn %sTable\n}\n\n", name, name)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by gqlgen. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	if len(imports) > 0 {
+		out.WriteString("import (\n")
+		for _, imp := range []string{"\"time\"", "gql \"github.com/arianito/gql/pkg\""} {
+			if imports[imp] {
+				out.WriteString("\t" + imp + "\n")
+			}
+		}
+		out.WriteString(")\n\n")
+	}
+	out.Write(body.Bytes())
+	return format.Source(out.Bytes())
+}
+
+// tag keeps a lone auto increment "id" implicit and spells out any other key.
+func tag(column gql.ColumnInfo, columns []gql.ColumnInfo) string {
+	pks := 0
+	for _, c := range columns {
+		if c.PrimaryKey {
+			pks++
+		}
+	}
+	if column.Name == "id" && column.PrimaryKey && column.AutoIncrement && pks == 1 {
+		return column.Name
+	}
+	out := column.Name
+	if column.PrimaryKey {
+		out += ",pk"
+	}
+	if column.AutoIncrement {
+		out += ",autoincrement"
+	}
+	return out
+}
+
+var typeName = regexp.MustCompile(`^[a-z0-9 ]+`)
+
+// goTypes maps the type names of the catalogs, without size and unsigned, to Go types.
+var goTypes = map[string]string{
+	"bool": "bool", "boolean": "bool", "bit": "bool",
+	"tinyint": "int64", "smallint": "int64", "mediumint": "int64", "int": "int64", "integer": "int64",
+	"bigint": "int64", "int2": "int64", "int4": "int64", "int8": "int64",
+	"smallserial": "int64", "serial": "int64", "bigserial": "int64",
+	"real": "float64", "float": "float64", "double": "float64", "double precision": "float64",
+	"float4": "float64", "float8": "float64",
+	"date": "time.Time", "datetime": "time.Time", "datetime2": "time.Time", "datetimeoffset": "time.Time",
+	"smalldatetime": "time.Time", "timestamp": "time.Time", "timestamptz": "time.Time",
+	"timestamp with time zone": "time.Time", "timestamp without time zone": "time.Time",
+	"blob": "[]byte", "tinyblob": "[]byte", "mediumblob": "[]byte", "longblob": "[]byte",
+	"binary": "[]byte", "varbinary": "[]byte", "bytea": "[]byte", "image": "[]byte",
+}
+
+var nullTypes = map[string]string{
+	"bool": "gql.NullBool", "int64": "gql.NullInt64", "float64": "gql.NullFloat64",
+	"time.Time": "gql.NullTime", "string": "gql.NullString",
+}
+
+func goType(column gql.ColumnInfo) string {
+	full := strings.ToLower(strings.TrimSpace(column.Type))
+	base := strings.TrimSpace(typeName.FindString(full))
+	base = strings.TrimSpace(strings.TrimSuffix(base, " unsigned"))
+	typ, ok := goTypes[base]
+	if !ok {
+		typ = "string"
+	}
+	if full == "tinyint(1)" {
+		typ = "bool"
+	}
+	if null, ok := nullTypes[typ]; ok && column.Nullable {
+		return null
+	}
+	return typ
+}
+
+var initialisms = map[string]string{
+	"id": "ID", "url": "URL", "uri": "URI", "api": "API", "http": "HTTP", "uuid": "UUID",
+	"json": "JSON", "sql": "SQL", "ip": "IP", "html": "HTML", "xml": "XML",
+}
+
+func goName(name string) string {
+	var out strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if upper, ok := initialisms[strings.ToLower(word)]; ok {
+			out.WriteString(upper)
+			continue
+		}
+		runes := []rune(word)
+		out.WriteString(string(unicode.ToUpper(runes[0])) + string(runes[1:]))
+	}
+	if out.Len() == 0 || unicode.IsDigit([]rune(out.String())[0]) {
+		return "X" + out.String()
+	}
+	return out.String()
+}
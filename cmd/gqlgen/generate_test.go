@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	gql "github.com/arianito/gql/pkg"
+)
+
+func TestGoType(t *testing.T) {
+	for typ, want := range map[string]string{
+		"INT(11) unsigned": "int64", "bigint": "int64", "integer": "int64", "tinyint(1)": "bool", "boolean": "bool",
+		"double precision": "float64", "timestamp without time zone": "time.Time", "datetime2": "time.Time",
+		"varchar(20)": "string", "longblob": "[]byte", "bytea": "[]byte", "interval": "string", "point": "string",
+	} {
+		if got := goType(gql.ColumnInfo{Type: typ}); got != want {
+			t.Errorf("%s: %s, want %s", typ, got, want)
+		}
+	}
+	if got := goType(gql.ColumnInfo{Type: "int", Nullable: true}); got != "gql.NullInt64" {
+		t.Error(got)
+	}
+	if got := goType(gql.ColumnInfo{Type: "blob", Nullable: true}); got != "[]byte" {
+		t.Error(got)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	out, err := generate("models", []gql.TableInfo{{
+		Name: "order_items",
+		Columns: []gql.ColumnInfo{
+			{Name: "order_id", Type: "int", PrimaryKey: true},
+			{Name: "item_id", Type: "int", PrimaryKey: true},
+			{Name: "api_url", Type: "text", Nullable: true},
+		},
+	}, {
+		Name: "categories",
+		Columns: []gql.ColumnInfo{
+			{Name: "id", Type: "integer", PrimaryKey: true, AutoIncrement: true},
+			{Name: "created_at", Type: "timestamp"},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"// Code generated by gqlgen. DO NOT EDIT.",
+		"\t\"time\"\n",
+		"type OrderItem struct {",
+		"OrderID int64          `gql:\"order_id,pk\"`",
+		"APIURL  gql.NullString `gql:\"api_url\"`",
+		"const OrderItemTable = \"order_items\"",
+		"type Category struct {",
+		"ID        int64     `gql:\"id\"`",
+		"CreatedAt: \"created_at\",",
+		"func (Category) TableName() string {",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Command gqlgen generates gql models from the tables of a live database.
+//
+// Drivers are linked in by build tags and aren't dependencies of gql, so the module building the tool
+// has to require the driver of its tag:
+//
+//	mysql      github.com/go-sql-driver/mysql
+//	postgres   github.com/lib/pq
+//	sqlite     github.com/mattn/go-sqlite3 (needs cgo)
+//	sqlserver  github.com/microsoft/go-mssqldb
+//
+// e.g. from the module the models are generated for:
+//
+//	go get github.com/arianito/gql github.com/lib/pq
+//	go run -tags postgres github.com/arianito/gql/cmd/gqlgen -driver postgres -dsn "$DSN" -pkg models -out models/models.go
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	gql "github.com/arianito/gql/pkg"
+)
+
+var dialects = map[string]gql.Dialect{
+	"mysql":     gql.MySQL,
+	"postgres":  gql.PostgreSQL,
+	"pgx":       gql.PostgreSQL,
+	"sqlite3":   gql.SQLite,
+	"sqlite":    gql.SQLite,
+	"sqlserver": gql.SQLServer,
+	"mssql":     gql.SQLServer,
+}
+
+func main() {
+	driver := flag.String("driver", "", "database/sql driver name: mysql, postgres, sqlite3 or sqlserver")
+	dsn := flag.String("dsn", "", "data source name")
+	pkg := flag.String("pkg", "models", "package of the generated file")
+	out := flag.String("out", "", "output file, stdout when empty")
+	tables := flag.String("tables", "", "comma separated tables, all of them when empty")
+	flag.Parse()
+
+	if err := run(*driver, *dsn, *pkg, *out, *tables); err != nil {
+		fmt.Fprintln(os.Stderr, "gqlgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(driver, dsn, pkg, out, tables string) error {
+	d, ok := dialects[driver]
+	if !ok {
+		return fmt.Errorf("unknown driver %q", driver)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return fmt.Errorf("%w, build with -tags mysql, postgres, sqlite or sqlserver", err)
+	}
+	defer db.Close()
+
+	var names []string
+	if tables != "" {
+		names = strings.Split(tables, ",")
+	}
+	info, err := gql.Inspect(context.Background(), db, d, names...)
+	if err != nil {
+		return err
+	}
+	src, err := generate(pkg, info)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0644)
+}
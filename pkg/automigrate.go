@@ -0,0 +1,227 @@
+package gql
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Schema creates the tables, columns and indexes models declare but the database lacks. Columns take
+// their type from the Go type unless tagged with type=, and are NOT NULL unless the field is a pointer,
+// a slice or a Null* type, or is added to an existing table without a default. These tag options apply:
+//
+//	size=64        length of a string column, 255 by default
+//	notnull        NOT NULL for any field
+//	default=expr   default value as an SQL expression
+//	unique         unique column
+//	index          index of its own, index=name shares the index with the fields of the same name
+//	uniqueindex    the same for a unique index
+//
+// Nothing is ever altered or dropped.
+type Schema struct {
+	statement
+}
+
+func NewSchema(e Executor) *Schema {
+	return &Schema{statement{exec: e}}
+}
+
+func (s *Schema) WithDialect(d Dialect) *Schema {
+	s.dialect = d
+	return s
+}
+
+func (s *Schema) WithContext(ctx context.Context) *Schema {
+	s.ctx = ctx
+	return s
+}
+
+// AutoMigrate applies the plan of the models with the default dialect.
+func AutoMigrate(e Executor, models ...interface{}) error {
+	return NewSchema(e).Migrate(models...).GetError()
+}
+
+func (s *Schema) Migrate(models ...interface{}) *Schema {
+	plan, err := s.Plan(models...)
+	s.err = s.run(plan, err)
+	return s
+}
+
+// Plan returns the statements Migrate would run, for review.
+func (s *Schema) Plan(models ...interface{}) (plan []string, err error) {
+	d := s.getDialect()
+	c, err := catalogOf(d)
+	if err != nil {
+		return nil, err
+	}
+	if s.exec == nil {
+		return nil, ErrNoExecutor
+	}
+	for _, model := range models {
+		m, err := GetModel(model)
+		if err != nil {
+			return nil, err
+		}
+		described, err := describe(s.getContext(), s.exec, d, c, m.Table)
+		if err != nil {
+			return nil, err
+		}
+		columns := make(map[string]bool)
+		for _, column := range described {
+			columns[strings.ToLower(column.Name)] = true
+		}
+		_, _, indexesQuery := c.catalog()
+		indexes, err := s.names(indexesQuery, m.Table)
+		if err != nil {
+			return nil, err
+		}
+		if len(columns) == 0 {
+			table := CreateTable(m.Table).WithDialect(d)
+			if len(m.PrimaryKeys) > 1 {
+				pk := make([]string, len(m.PrimaryKeys))
+				for i, f := range m.PrimaryKeys {
+					pk[i] = f.Column
+				}
+				table.PrimaryKey(pk...)
+			}
+			for _, f := range m.Fields {
+				if f.Tagged {
+					table.Column(f.Column, columnOf(f))
+				}
+			}
+			plan = append(plan, table.Query())
+		} else {
+			alter := AlterTable(m.Table).WithDialect(d)
+			for _, f := range m.Fields {
+				if f.Tagged && !columns[strings.ToLower(f.Column)] {
+					c := columnOf(f)
+					// the rows already there have no value, only a default fills them
+					if _, ok := f.Options["notnull"]; !ok && !c.hasDefault {
+						c.notNull = false
+					}
+					alter.AddColumn(f.Column, c)
+				}
+			}
+			plan = append(plan, alter.Queries()...)
+		}
+		for _, index := range indexesOf(m) {
+			if !indexes[strings.ToLower(index.name)] {
+				plan = append(plan, index.WithDialect(d).Query())
+			}
+		}
+	}
+	return
+}
+
+func (s *Schema) names(query string, table string) (map[string]bool, error) {
+	rows, err := s.exec.QueryContext(s.getContext(), query, table)
+	if err != nil {
+		return nil, newQueryError(s.getDialect(), query, []interface{}{table}, err)
+	}
+	defer rows.Close()
+	out := make(map[string]bool)
+	for rows.Next() {
+		var name sql.NullString
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		out[strings.ToLower(name.String)] = true
+	}
+	return out, rows.Err()
+}
+
+var bytesType = reflect.TypeOf([]byte(nil))
+
+func columnOf(f *FieldInfo) *ColumnDef {
+	c := &ColumnDef{kind: kindOf(f.Type)}
+	if kind, ok := f.Options["type"]; ok && kind != "" {
+		c.kind = kind
+	}
+	if c.kind == TypeVarchar {
+		c.size = 255
+	}
+	if size, err := strconv.Atoi(f.Options["size"]); err == nil {
+		c.size = size
+	}
+	c.primaryKey = f.PrimaryKey
+	c.autoIncrement = f.AutoIncrement
+	// a nil slice is written as NULL, like a nil pointer or an invalid Null* value
+	_, c.notNull = f.Options["notnull"]
+	c.notNull = c.notNull || !nullable(f.Type) && f.Type.Kind() != reflect.Slice
+	_, c.unique = f.Options["unique"]
+	if def, ok := f.Options["default"]; ok {
+		c.Default(Sql(def))
+	}
+	return c
+}
+
+func kindOf(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType, reflect.TypeOf(NullTime{}), reflect.TypeOf(sql.NullTime{}):
+		return TypeTimestamp
+	case reflect.TypeOf(NullString{}), reflect.TypeOf(sql.NullString{}):
+		return TypeVarchar
+	case reflect.TypeOf(NullInt64{}), reflect.TypeOf(sql.NullInt64{}):
+		return TypeBigInt
+	case reflect.TypeOf(NullInt32{}), reflect.TypeOf(sql.NullInt32{}):
+		return TypeInt
+	case reflect.TypeOf(NullBool{}), reflect.TypeOf(sql.NullBool{}):
+		return TypeBool
+	case reflect.TypeOf(NullFloat64{}), reflect.TypeOf(sql.NullFloat64{}):
+		return TypeFloat
+	case bytesType:
+		return TypeBinary
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return TypeBool
+	case reflect.Int8, reflect.Int16, reflect.Uint8, reflect.Uint16:
+		return TypeSmallInt
+	case reflect.Int32, reflect.Uint32:
+		return TypeInt
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return TypeBigInt
+	case reflect.Float32, reflect.Float64:
+		return TypeFloat
+	case reflect.String:
+		return TypeVarchar
+	}
+	return TypeText
+}
+
+func indexesOf(m *ModelInfo) (out []*IndexBuilder) {
+	named := make(map[string]*IndexBuilder)
+	for _, f := range m.Fields {
+		if !f.Tagged {
+			continue
+		}
+		for _, option := range []string{"index", "uniqueindex"} {
+			name, ok := f.Options[option]
+			if !ok {
+				continue
+			}
+			if name == "" {
+				name = "idx_" + m.Table + "_" + f.Column
+				if option == "uniqueindex" {
+					name = "uidx_" + m.Table + "_" + f.Column
+				}
+			}
+			index, ok := named[name]
+			if !ok {
+				index = CreateIndex(name).On(m.Table)
+				if option == "uniqueindex" {
+					index.Unique()
+				}
+				named[name] = index
+				out = append(out, index)
+			}
+			index.columns = append(index.columns, f.Column)
+		}
+	}
+	return
+}
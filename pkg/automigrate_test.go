@@ -0,0 +1,69 @@
+package gql
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+type migrated struct {
+	ID    int64      `gql:"id"`
+	Email string     `gql:"email,size=120,uniqueindex"`
+	Name  NullString `gql:"name,index=idx_name_age"`
+	Age   int32      `gql:"age,notnull,default=0,index=idx_name_age"`
+	Bio   string     `gql:"bio,type=text"`
+	Note  *string    `gql:"note"`
+	Data  []byte     `gql:"data"`
+}
+
+func TestColumnOf(t *testing.T) {
+	m, err := GetModel(&migrated{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := CreateTable(m.Table).WithDialect(PostgreSQL)
+	for _, f := range m.Fields {
+		table.Column(f.Column, columnOf(f))
+	}
+	want := `CREATE TABLE "migrateds" ("id" BIGSERIAL PRIMARY KEY, "email" VARCHAR(120) NOT NULL, "name" VARCHAR(255), "age" INTEGER NOT NULL DEFAULT 0, "bio" TEXT NOT NULL, "note" VARCHAR(255), "data" BYTEA)`
+	if got := table.Query(); got != want {
+		t.Errorf("\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestIndexesOf(t *testing.T) {
+	m, _ := GetModel(&migrated{})
+	var got []string
+	for _, index := range indexesOf(m) {
+		got = append(got, index.WithDialect(PostgreSQL).Query())
+	}
+	want := []string{
+		`CREATE UNIQUE INDEX "uidx_migrateds_email" ON "migrateds" ("email")`,
+		`CREATE INDEX "idx_name_age" ON "migrateds" ("name", "age")`,
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestPlanAddColumn(t *testing.T) {
+	db, _ := newFakeDB(t, fakeAnswer{
+		columns: []string{"name", "type", "nullable", "pk", "auto"},
+		rows:    [][]driver.Value{{"id", "bigint", false, true, true}, {"email", "varchar", false, false, false}, {"name", "varchar", true, false, false}},
+	})
+	plan, err := NewSchema(db).WithDialect(PostgreSQL).Plan(&migrated{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`ALTER TABLE "migrateds" ADD COLUMN "age" INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE "migrateds" ADD COLUMN "bio" TEXT`,
+		`ALTER TABLE "migrateds" ADD COLUMN "note" VARCHAR(255)`,
+		`ALTER TABLE "migrateds" ADD COLUMN "data" BYTEA`,
+		`CREATE UNIQUE INDEX "uidx_migrateds_email" ON "migrateds" ("email")`,
+		`CREATE INDEX "idx_name_age" ON "migrateds" ("name", "age")`,
+	}
+	if !reflect.DeepEqual(plan, want) {
+		t.Errorf("\n got %q\nwant %q", plan, want)
+	}
+}
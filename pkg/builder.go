@@ -1,5 +1,7 @@
 package gql
 
+import "context"
+
 type Builder interface {
 	Table(table string) Builder
 	Columns(columns ...interface{}) Builder
@@ -34,21 +36,40 @@ type Builder interface {
 	WhereBetween(clause string, value1 interface{}, value2 interface{}) Builder
 	WhereIn(field string, value []interface{}) Builder
 	WhereInQuery(field string, fn func(b Builder)) Builder
+	WhereNotInQuery(field string, fn func(b Builder)) Builder
+	WhereExists(fn func(b Builder)) Builder
+	WhereNotExists(fn func(b Builder)) Builder
+	FromSub(builder Builder, alias string) Builder
 	Or() Builder
 	And() Builder
 	AndNot() Builder
 	Fill(values ...*OBJ) Builder
+	OnConflict(columns ...string) Builder
+	DoUpdate(columns ...string) Builder
+	DoNothing() Builder
+	Returning(columns ...string) Builder
+	Unsafe() Builder
+	WithTrashed() Builder
+	OnlyTrashed() Builder
+	ForceDelete() Builder
+	Preload(relations ...string) Builder
 	Set(key string, value interface{}) Builder
 	Bind(o interface{}) Builder
 	BindExclude(o interface{}, keys ...string) Builder
 	BindOnly(o interface{}, keys ...string) Builder
 	Field(name string) SqlReserved
-	Use(a interface{}) Builder
+	Use(e Executor) Builder
+	WithDialect(d Dialect) Builder
+	WithContext(ctx context.Context) Builder
 	Query() string
+	QueryWithArgs() (string, []interface{})
 	Chunk(length int64, callback func(Scan func(o interface{}) Builder)) Builder
 	Paginate(page int64, take int64) Builder
+	Keyset(take int64, cursor string) Builder
+	NextCursor(cursor *string) Builder
 	Scan(o interface{}) Builder
 	First(o interface{}) Builder
+	Cursor() (*Cursor, error)
 	Count(count *int64) Builder
 	LastInsertionId(id *int64) Builder
 	RowsAffected(count *int64) Builder
@@ -0,0 +1,79 @@
+package gql
+
+import (
+	"database/sql"
+	"reflect"
+	"time"
+)
+
+var clock = time.Now
+
+// SetClock replaces the source of the autocreatetime, autoupdatetime and softdelete stamps, nil
+// restores time.Now.
+func SetClock(fn func() time.Time) {
+	if fn == nil {
+		fn = time.Now
+	}
+	clock = fn
+}
+
+// Clock returns the current time of the clock given to SetClock.
+func Clock() time.Time {
+	return clock()
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// setTime stores t into a time.Time, *time.Time, unix seconds integer or sql.Scanner field.
+func setTime(v reflect.Value, t time.Time) {
+	switch {
+	case v.Type() == timeType:
+		v.Set(reflect.ValueOf(t))
+	case v.Kind() == reflect.Ptr && v.Type().Elem() == timeType:
+		v.Set(reflect.ValueOf(&t))
+	case v.Kind() >= reflect.Int && v.Kind() <= reflect.Uint64:
+		setInt(v, t.Unix())
+	default:
+		if scanner, ok := v.Addr().Interface().(sql.Scanner); ok {
+			scanner.Scan(t)
+		}
+	}
+}
+
+// timeValue is t in the shape of the field, for columns set without a bound struct.
+func timeValue(f *FieldInfo, t time.Time) interface{} {
+	v := reflect.New(f.Type).Elem()
+	setTime(v, t)
+	return reflect.Indirect(v).Interface()
+}
+
+// stamp reports whether the field takes the current time in a statement of the given type.
+func (f *FieldInfo) stamp(typ SqlTyp, zero bool) bool {
+	if typ == SqlTypCreate {
+		return f.AutoUpdateTime || f.AutoCreateTime && zero
+	}
+	return typ == SqlTypUpdate && f.AutoUpdateTime
+}
+
+// stampTimes adds the automatic timestamps of the model to inserts and updates built with Set.
+func (b *QueryBuilder) stampTimes() {
+	if b.model == nil || b.obj != nil || len(b.values) == 0 || b.keys == nil {
+		return
+	}
+	now := clock()
+	for _, f := range b.model.Fields {
+		if f.stamp(b.typ, true) && !some(b.keys, func(key string) bool {
+			return key == f.Column
+		}) {
+			b.Set(f.Column, timeValue(f, now))
+		}
+	}
+}
+
+func (b *QueryBuilder) stampFields(v reflect.Value, fields []*FieldInfo, now time.Time) {
+	for _, f := range fields {
+		if value, ok := fieldOf(v, f.Index, true); ok && f.stamp(b.typ, value.IsZero()) {
+			setTime(value, now)
+		}
+	}
+}
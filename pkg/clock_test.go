@@ -0,0 +1,67 @@
+package gql
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type stampedPost struct {
+	ID        int64     `gql:"id"`
+	Title     string    `gql:"title"`
+	CreatedAt time.Time `gql:"created_at,autocreatetime"`
+	UpdatedAt int64     `gql:"updated_at,autoupdatetime"`
+}
+
+func TestClock(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	SetClock(func() time.Time { return now })
+	defer SetClock(nil)
+	db, f := newFakeDB(t)
+
+	post := stampedPost{Title: "a"}
+	if err := Create("").WithDialect(PostgreSQL).Bind(&post).Use(db).Run().GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if !post.CreatedAt.Equal(now) || post.UpdatedAt != now.Unix() {
+		t.Errorf("insert stamped %+v", post)
+	}
+
+	earlier := now.Add(-time.Hour)
+	post = stampedPost{ID: 1, Title: "b", CreatedAt: earlier}
+	if err := Create("").WithDialect(PostgreSQL).Bind(&post).Use(db).Run().GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if !post.CreatedAt.Equal(earlier) {
+		t.Errorf("insert overwrote a set creation time: %v", post.CreatedAt)
+	}
+
+	post.UpdatedAt = 0
+	if err := Update("").WithDialect(PostgreSQL).Bind(&post).Where("id", 1).Use(db).Run().GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if post.UpdatedAt != now.Unix() {
+		t.Errorf("update stamped %+v", post)
+	}
+
+	if err := Update("").WithDialect(PostgreSQL).Model(&stampedPost{}).Set("title", "c").Where("id", 1).Use(db).Run().GetError(); err != nil {
+		t.Fatal(err)
+	}
+
+	checkStatements(t, f,
+		`INSERT INTO "stamped_posts"("title", "created_at", "updated_at") VALUES($1, $2, $3) RETURNING "id"`,
+		`INSERT INTO "stamped_posts"("title", "created_at", "updated_at") VALUES($1, $2, $3) RETURNING "id"`,
+		`UPDATE "stamped_posts" SET "title"=$1, "updated_at"=$2 WHERE "id" = $3`,
+		`UPDATE "stamped_posts" SET "title"=$1, "updated_at"=$2 WHERE "id" = $3`,
+	)
+	want := [][]driver.Value{
+		{"a", now, now.Unix()},
+		{"b", earlier, now.Unix()},
+		{"b", now.Unix(), int64(1)},
+		{"c", now.Unix(), int64(1)},
+	}
+	if !reflect.DeepEqual(f.args, want) {
+		t.Errorf("args %v\nwant %v", f.args, want)
+	}
+}
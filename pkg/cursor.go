@@ -0,0 +1,129 @@
+package gql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// Cursor walks the rows of a single query without materializing them, it must be closed.
+type Cursor struct {
+	ctx     context.Context
+	rows    *sql.Rows
+	columns []string
+	ifc     []interface{}
+	err     error
+}
+
+func (b *QueryBuilder) Cursor() (*Cursor, error) {
+	rows, err := b.query()
+	if err != nil {
+		b.err = err
+		return nil, err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		b.err = err
+		return nil, err
+	}
+	return &Cursor{ctx: b.getContext(), rows: rows, columns: columns}, nil
+}
+
+func (c *Cursor) Next() bool {
+	if c.err != nil {
+		return false
+	}
+	return c.rows.Next()
+}
+
+// Scan maps the current row into o, a pointer to a struct.
+func (c *Cursor) Scan(o interface{}) error {
+	v := reflect.ValueOf(o)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		c.err = fmt.Errorf("%w: cannot scan into %T", ErrInvalidTarget, o)
+		return c.err
+	}
+	model, err := getModel(v.Type())
+	if err != nil {
+		c.err = err
+		return err
+	}
+	c.ifc = model.plan(c.columns).targets(v.Elem(), c.ifc)
+	if err = c.rows.Scan(c.ifc...); err == nil {
+		err = afterFind(c.ctx, o)
+	}
+	c.err = err
+	return err
+}
+
+func (c *Cursor) Err() error {
+	if c.err != nil {
+		return c.err
+	}
+	return c.rows.Err()
+}
+
+func (c *Cursor) Close() error {
+	return c.rows.Close()
+}
+
+type Iterator[T any] struct {
+	cursor *Cursor
+	value  T
+}
+
+// Rows runs the query of b and returns an iterator mapping each row into T, a struct or a pointer to one.
+func Rows[T any](ctx context.Context, b Builder) (*Iterator[T], error) {
+	cursor, err := b.WithContext(ctx).Cursor()
+	if err != nil {
+		return nil, err
+	}
+	return &Iterator[T]{cursor: cursor}, nil
+}
+
+func (it *Iterator[T]) Next() bool {
+	if !it.cursor.Next() {
+		return false
+	}
+	var value T
+	target := reflect.ValueOf(&value).Elem()
+	if target.Kind() == reflect.Ptr {
+		target.Set(reflect.New(target.Type().Elem()))
+	} else {
+		target = target.Addr()
+	}
+	if it.cursor.Scan(target.Interface()) != nil {
+		return false
+	}
+	it.value = value
+	return true
+}
+
+func (it *Iterator[T]) Value() T {
+	return it.value
+}
+
+func (it *Iterator[T]) Err() error {
+	return it.cursor.Err()
+}
+
+func (it *Iterator[T]) Close() error {
+	return it.cursor.Close()
+}
+
+// Each calls fn for every row of b, stopping at the first error fn returns.
+func Each[T any](ctx context.Context, b Builder, fn func(T) error) (err error) {
+	it, err := Rows[T](ctx, b)
+	if err != nil {
+		return
+	}
+	defer it.Close()
+	for it.Next() {
+		if err = fn(it.Value()); err != nil {
+			return
+		}
+	}
+	return it.Err()
+}
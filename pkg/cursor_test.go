@@ -0,0 +1,102 @@
+package gql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+type cursorUser struct {
+	ID   int64  `gql:"id"`
+	Name string `gql:"name"`
+}
+
+func cursorRows() fakeAnswer {
+	return fakeAnswer{columns: []string{"id", "name"}, rows: [][]driver.Value{{1, "a"}, {2, "b"}, {3, "c"}}}
+}
+
+func TestCursorRows(t *testing.T) {
+	ctx := context.Background()
+	db, f := newFakeDB(t, cursorRows())
+	c, err := Read("").Model(&cursorUser{}).Use(db).WithDialect(PostgreSQL).Where("id", 1).Cursor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names string
+	for c.Next() {
+		var u cursorUser
+		if err = c.Scan(&u); err != nil {
+			t.Fatal(err)
+		}
+		names += u.Name
+	}
+	if err = c.Err(); err != nil || names != "abc" {
+		t.Errorf("%q, %v", names, err)
+	}
+	c.Close()
+	checkStatements(t, f, `SELECT * FROM "cursor_users" WHERE "id" = $1`)
+
+	db, _ = newFakeDB(t, cursorRows())
+	it, err := Rows[*cursorUser](ctx, Read("").Model(&cursorUser{}).Use(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for it.Next() {
+		ids = append(ids, it.Value().ID)
+	}
+	it.Close()
+	if it.Err() != nil || len(ids) != 3 || ids[2] != 3 {
+		t.Errorf("%v, %v", ids, it.Err())
+	}
+
+	db, _ = newFakeDB(t, cursorRows())
+	stop := errors.New("stop")
+	names = ""
+	err = Each(ctx, Read("").Model(&cursorUser{}).Use(db), func(u cursorUser) error {
+		names += u.Name
+		if u.ID == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || names != "ab" {
+		t.Errorf("%q, %v", names, err)
+	}
+
+	db, _ = newFakeDB(t, fakeAnswer{err: errors.New("down")})
+	if _, err = Rows[cursorUser](ctx, Read("users").Use(db)); err == nil {
+		t.Error("no error from a failing query")
+	}
+}
+
+func TestBuilderError(t *testing.T) {
+	type pair struct {
+		A int64 `gql:"a,pk"`
+		B int64 `gql:"b,pk"`
+	}
+	ctx := context.Background()
+	for name, b := range map[string]func() Builder{
+		"keyset": func() Builder { return Read("").Model(&cursorUser{}).OrderBy("id").Keyset(2, "garbage") },
+		"find":   func() Builder { return Read("").Model(&pair{}).Find(1) },
+		"model":  func() Builder { return Read("users").Model(1) },
+	} {
+		db, f := newFakeDB(t, cursorRows())
+		err := Each(ctx, b().Use(db), func(cursorUser) error {
+			t.Errorf("%s: got a row", name)
+			return nil
+		})
+		if err == nil {
+			t.Errorf("%s: no error", name)
+		}
+		var users []cursorUser
+		if b().Use(db).Scan(&users).GetError() == nil {
+			t.Errorf("%s: scanned without an error", name)
+		}
+		if q, _ := b().QueryWithArgs(); q != "" {
+			t.Errorf("%s: rendered %s", name, q)
+		}
+		checkStatements(t, f)
+	}
+}
@@ -0,0 +1,510 @@
+package gql
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// Column kinds, each dialect maps them to its own type through DataType.
+const (
+	TypeBool      = "bool"
+	TypeSmallInt  = "smallint"
+	TypeInt       = "int"
+	TypeBigInt    = "bigint"
+	TypeFloat     = "float"
+	TypeDecimal   = "decimal"
+	TypeVarchar   = "varchar"
+	TypeText      = "text"
+	TypeBinary    = "binary"
+	TypeDate      = "date"
+	TypeTimestamp = "timestamp"
+)
+
+func genericType(kind string, size int, scale int) string {
+	switch kind {
+	case TypeBool:
+		return "BOOLEAN"
+	case TypeSmallInt:
+		return "SMALLINT"
+	case TypeInt:
+		return "INTEGER"
+	case TypeBigInt:
+		return "BIGINT"
+	case TypeDecimal:
+		return "DECIMAL(" + strconv.Itoa(size) + ", " + strconv.Itoa(scale) + ")"
+	case TypeVarchar:
+		return "VARCHAR(" + strconv.Itoa(size) + ")"
+	case TypeText:
+		return "TEXT"
+	case TypeDate:
+		return "DATE"
+	case TypeTimestamp:
+		return "TIMESTAMP"
+	}
+	return strings.ToUpper(kind)
+}
+
+type ColumnDef struct {
+	kind          string
+	size          int
+	scale         int
+	primaryKey    bool
+	autoIncrement bool
+	notNull       bool
+	unique        bool
+	def           interface{}
+	hasDefault    bool
+}
+
+func Bool() *ColumnDef {
+	return &ColumnDef{kind: TypeBool}
+}
+
+func SmallInt() *ColumnDef {
+	return &ColumnDef{kind: TypeSmallInt}
+}
+
+func Int() *ColumnDef {
+	return &ColumnDef{kind: TypeInt}
+}
+
+func BigInt() *ColumnDef {
+	return &ColumnDef{kind: TypeBigInt}
+}
+
+func Float() *ColumnDef {
+	return &ColumnDef{kind: TypeFloat}
+}
+
+func Decimal(precision int, scale int) *ColumnDef {
+	return &ColumnDef{kind: TypeDecimal, size: precision, scale: scale}
+}
+
+// Varchar is a string of at most size characters, 255 when size is 0.
+func Varchar(size int) *ColumnDef {
+	if size <= 0 {
+		size = 255
+	}
+	return &ColumnDef{kind: TypeVarchar, size: size}
+}
+
+func Text() *ColumnDef {
+	return &ColumnDef{kind: TypeText}
+}
+
+func Binary() *ColumnDef {
+	return &ColumnDef{kind: TypeBinary}
+}
+
+func Date() *ColumnDef {
+	return &ColumnDef{kind: TypeDate}
+}
+
+func Timestamp() *ColumnDef {
+	return &ColumnDef{kind: TypeTimestamp}
+}
+
+// Type is a column of any other kind, rendered as is by dialects that don't know it.
+func Type(kind string) *ColumnDef {
+	return &ColumnDef{kind: kind}
+}
+
+func (c *ColumnDef) PrimaryKey() *ColumnDef {
+	c.primaryKey = true
+	return c
+}
+
+func (c *ColumnDef) AutoIncrement() *ColumnDef {
+	c.autoIncrement = true
+	return c
+}
+
+func (c *ColumnDef) NotNull() *ColumnDef {
+	c.notNull = true
+	return c
+}
+
+func (c *ColumnDef) Unique() *ColumnDef {
+	c.unique = true
+	return c
+}
+
+// Default sets the default value, inlined like Query does, use Sql() or Now() for expressions.
+func (c *ColumnDef) Default(value interface{}) *ColumnDef {
+	c.def = value
+	c.hasDefault = true
+	return c
+}
+
+func (c *ColumnDef) render(d Dialect, name string, inlinePk bool) string {
+	out := quoteName(d, name) + " " + d.DataType(c.kind, c.size, c.scale, c.autoIncrement)
+	if c.notNull && !c.primaryKey {
+		out += " NOT NULL"
+	}
+	if c.hasDefault {
+		out += " DEFAULT " + convert(d, c.def)
+	}
+	if c.unique && !c.primaryKey {
+		out += " UNIQUE"
+	}
+	if c.primaryKey && inlinePk {
+		out += " PRIMARY KEY"
+	}
+	return out
+}
+
+type statement struct {
+	dialect Dialect
+	ctx     context.Context
+	exec    Executor
+	err     error
+}
+
+func (s *statement) getDialect() Dialect {
+	if s.dialect != nil {
+		return s.dialect
+	}
+	return defaultDialect
+}
+
+func (s *statement) getContext() context.Context {
+	if s.ctx != nil {
+		return s.ctx
+	}
+	return context.Background()
+}
+
+// GetError returns the error of the last Run, like it does on queries.
+func (s *statement) GetError() error {
+	return s.err
+}
+
+func (s *statement) run(queries []string, err error) error {
+	if err != nil {
+		return err
+	}
+	if s.exec == nil {
+		return ErrNoExecutor
+	}
+	for _, query := range queries {
+		if enableLog {
+			log.Println(query)
+		}
+		if _, err = s.exec.ExecContext(s.getContext(), query); err != nil {
+			return newQueryError(s.getDialect(), query, nil, err)
+		}
+	}
+	return nil
+}
+
+type tableColumn struct {
+	name string
+	def  *ColumnDef
+}
+
+type CreateTableBuilder struct {
+	statement
+	name        string
+	ifNotExists bool
+	columns     []tableColumn
+	primaryKey  []string
+}
+
+func CreateTable(name string) *CreateTableBuilder {
+	return &CreateTableBuilder{name: name}
+}
+
+func (t *CreateTableBuilder) WithDialect(d Dialect) *CreateTableBuilder {
+	t.dialect = d
+	return t
+}
+
+func (t *CreateTableBuilder) WithContext(ctx context.Context) *CreateTableBuilder {
+	t.ctx = ctx
+	return t
+}
+
+func (t *CreateTableBuilder) Use(e Executor) *CreateTableBuilder {
+	t.exec = e
+	return t
+}
+
+func (t *CreateTableBuilder) IfNotExists() *CreateTableBuilder {
+	t.ifNotExists = true
+	return t
+}
+
+func (t *CreateTableBuilder) Column(name string, def *ColumnDef) *CreateTableBuilder {
+	t.columns = append(t.columns, tableColumn{name: name, def: def})
+	return t
+}
+
+// PrimaryKey declares a primary key over several columns, instead of the ones marked on the columns.
+func (t *CreateTableBuilder) PrimaryKey(columns ...string) *CreateTableBuilder {
+	t.primaryKey = columns
+	return t
+}
+
+func (t *CreateTableBuilder) Query() string {
+	d := t.getDialect()
+	var pk []string
+	for _, column := range t.columns {
+		if column.def.primaryKey {
+			pk = append(pk, column.name)
+		}
+	}
+	if len(t.primaryKey) > 0 {
+		pk = t.primaryKey
+	}
+	inline := len(t.primaryKey) == 0 && len(pk) == 1
+	defs := make([]string, 0, len(t.columns)+1)
+	for _, column := range t.columns {
+		defs = append(defs, column.def.render(d, column.name, inline))
+	}
+	if !inline && len(pk) > 0 {
+		names := make([]string, len(pk))
+		for i, name := range pk {
+			names[i] = quoteName(d, name)
+		}
+		defs = append(defs, "PRIMARY KEY ("+strings.Join(names, ", ")+")")
+	}
+	out := "CREATE TABLE " + quoteName(d, t.name) + " (" + strings.Join(defs, ", ") + ")"
+	if t.ifNotExists {
+		out, _ = d.IfNotExists("TABLE", t.name, "", out)
+	}
+	return out
+}
+
+func (t *CreateTableBuilder) Run() *CreateTableBuilder {
+	t.err = t.run([]string{t.Query()}, nil)
+	return t
+}
+
+type alteration struct {
+	kind string
+	name string
+	to   string
+	def  *ColumnDef
+}
+
+type AlterTableBuilder struct {
+	statement
+	name    string
+	actions []alteration
+}
+
+// AlterTable changes the columns of a table, each change is run as its own statement since SQLite
+// accepts only one per ALTER TABLE.
+func AlterTable(name string) *AlterTableBuilder {
+	return &AlterTableBuilder{name: name}
+}
+
+func (t *AlterTableBuilder) WithDialect(d Dialect) *AlterTableBuilder {
+	t.dialect = d
+	return t
+}
+
+func (t *AlterTableBuilder) WithContext(ctx context.Context) *AlterTableBuilder {
+	t.ctx = ctx
+	return t
+}
+
+func (t *AlterTableBuilder) Use(e Executor) *AlterTableBuilder {
+	t.exec = e
+	return t
+}
+
+func (t *AlterTableBuilder) AddColumn(name string, def *ColumnDef) *AlterTableBuilder {
+	t.actions = append(t.actions, alteration{kind: "add", name: name, def: def})
+	return t
+}
+
+func (t *AlterTableBuilder) DropColumn(name string) *AlterTableBuilder {
+	t.actions = append(t.actions, alteration{kind: "drop", name: name})
+	return t
+}
+
+func (t *AlterTableBuilder) RenameColumn(from string, to string) *AlterTableBuilder {
+	t.actions = append(t.actions, alteration{kind: "rename", name: from, to: to})
+	return t
+}
+
+func (t *AlterTableBuilder) Queries() []string {
+	d := t.getDialect()
+	out := make([]string, len(t.actions))
+	for i, action := range t.actions {
+		switch action.kind {
+		case "add":
+			out[i] = d.AddColumn(t.name, action.def.render(d, action.name, true))
+		case "drop":
+			out[i] = "ALTER TABLE " + quoteName(d, t.name) + " DROP COLUMN " + quoteName(d, action.name)
+		case "rename":
+			out[i] = d.RenameColumn(t.name, action.name, action.to)
+		}
+	}
+	return out
+}
+
+func (t *AlterTableBuilder) Query() string {
+	return strings.Join(t.Queries(), "; ")
+}
+
+func (t *AlterTableBuilder) Run() *AlterTableBuilder {
+	t.err = t.run(t.Queries(), nil)
+	return t
+}
+
+type IndexBuilder struct {
+	statement
+	name     string
+	table    string
+	columns  []string
+	unique   bool
+	ifExists bool
+	drop     bool
+}
+
+func CreateIndex(name string) *IndexBuilder {
+	return &IndexBuilder{name: name}
+}
+
+func DropIndex(name string) *IndexBuilder {
+	return &IndexBuilder{name: name, drop: true}
+}
+
+func (x *IndexBuilder) WithDialect(d Dialect) *IndexBuilder {
+	x.dialect = d
+	return x
+}
+
+func (x *IndexBuilder) WithContext(ctx context.Context) *IndexBuilder {
+	x.ctx = ctx
+	return x
+}
+
+func (x *IndexBuilder) Use(e Executor) *IndexBuilder {
+	x.exec = e
+	return x
+}
+
+// On names the table and, when creating, the indexed columns. MySQL and SQL Server need the table to
+// drop an index too.
+func (x *IndexBuilder) On(table string, columns ...string) *IndexBuilder {
+	x.table = table
+	x.columns = columns
+	return x
+}
+
+func (x *IndexBuilder) Unique() *IndexBuilder {
+	x.unique = true
+	return x
+}
+
+// IfNotExists skips an existing index when creating, IfExists a missing one when dropping.
+func (x *IndexBuilder) IfNotExists() *IndexBuilder {
+	x.ifExists = true
+	return x
+}
+
+func (x *IndexBuilder) IfExists() *IndexBuilder {
+	x.ifExists = true
+	return x
+}
+
+func (x *IndexBuilder) build() (string, error) {
+	d := x.getDialect()
+	if x.drop {
+		return d.DropIndex(x.name, x.table, x.ifExists)
+	}
+	columns := make([]string, len(x.columns))
+	for i, column := range x.columns {
+		columns[i] = quoteName(d, column)
+	}
+	out := "CREATE INDEX "
+	if x.unique {
+		out = "CREATE UNIQUE INDEX "
+	}
+	out += quoteName(d, x.name) + " ON " + quoteName(d, x.table) + " (" + strings.Join(columns, ", ") + ")"
+	if x.ifExists {
+		return d.IfNotExists("INDEX", x.name, x.table, out)
+	}
+	return out, nil
+}
+
+// ifNotExists adds IF NOT EXISTS after the kind of object create makes.
+func ifNotExists(kind string, create string) string {
+	return strings.Replace(create, kind+" ", kind+" IF NOT EXISTS ", 1)
+}
+
+// dropIndex drops the index name, of table unless it's empty.
+func dropIndex(d Dialect, name string, table string, ifExists bool) string {
+	out := "DROP INDEX "
+	if ifExists {
+		out += "IF EXISTS "
+	}
+	out += quoteName(d, name)
+	if table != "" {
+		out += " ON " + quoteName(d, table)
+	}
+	return out
+}
+
+func renameColumn(d Dialect, table string, from string, to string) string {
+	return "ALTER TABLE " + quoteName(d, table) + " RENAME COLUMN " + quoteName(d, from) + " TO " + quoteName(d, to)
+}
+
+func (x *IndexBuilder) Query() string {
+	out, _ := x.build()
+	return out
+}
+
+func (x *IndexBuilder) Run() *IndexBuilder {
+	query, err := x.build()
+	x.err = x.run([]string{query}, err)
+	return x
+}
+
+type DropTableBuilder struct {
+	statement
+	name     string
+	ifExists bool
+}
+
+func DropTable(name string) *DropTableBuilder {
+	return &DropTableBuilder{name: name}
+}
+
+func (t *DropTableBuilder) WithDialect(d Dialect) *DropTableBuilder {
+	t.dialect = d
+	return t
+}
+
+func (t *DropTableBuilder) WithContext(ctx context.Context) *DropTableBuilder {
+	t.ctx = ctx
+	return t
+}
+
+func (t *DropTableBuilder) Use(e Executor) *DropTableBuilder {
+	t.exec = e
+	return t
+}
+
+func (t *DropTableBuilder) IfExists() *DropTableBuilder {
+	t.ifExists = true
+	return t
+}
+
+func (t *DropTableBuilder) Query() string {
+	d := t.getDialect()
+	if t.ifExists {
+		return "DROP TABLE IF EXISTS " + quoteName(d, t.name)
+	}
+	return "DROP TABLE " + quoteName(d, t.name)
+}
+
+func (t *DropTableBuilder) Run() *DropTableBuilder {
+	t.err = t.run([]string{t.Query()}, nil)
+	return t
+}
@@ -0,0 +1,97 @@
+package gql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCreateTable(t *testing.T) {
+	want := golden{
+		"mysql":     "CREATE TABLE IF NOT EXISTS `users` (`id` BIGINT AUTO_INCREMENT PRIMARY KEY, `name` VARCHAR(64) NOT NULL DEFAULT 'x', `active` TINYINT(1) DEFAULT true, `price` DECIMAL(10, 2), `created_at` DATETIME(6) DEFAULT NOW(6))",
+		"postgres":  `CREATE TABLE IF NOT EXISTS "users" ("id" BIGSERIAL PRIMARY KEY, "name" VARCHAR(64) NOT NULL DEFAULT 'x', "active" BOOLEAN DEFAULT TRUE, "price" NUMERIC(10, 2), "created_at" TIMESTAMP DEFAULT NOW())`,
+		"sqlite":    `CREATE TABLE IF NOT EXISTS "users" ("id" INTEGER PRIMARY KEY, "name" VARCHAR(64) NOT NULL DEFAULT 'x', "active" BOOLEAN DEFAULT 1, "price" DECIMAL(10, 2), "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP)`,
+		"sqlserver": "IF OBJECT_ID(N'users', N'U') IS NULL CREATE TABLE [users] ([id] BIGINT IDENTITY(1,1) PRIMARY KEY, [name] NVARCHAR(64) NOT NULL DEFAULT N'x', [active] BIT DEFAULT 1, [price] DECIMAL(10, 2), [created_at] DATETIME2 DEFAULT GETDATE())",
+	}
+	for _, td := range testDialects {
+		got := CreateTable("users").WithDialect(td.d).IfNotExists().
+			Column("id", BigInt().PrimaryKey().AutoIncrement()).
+			Column("name", Varchar(64).NotNull().Default("x")).
+			Column("active", Bool().Default(true)).
+			Column("price", Decimal(10, 2)).
+			Column("created_at", Timestamp().Default(Now())).Query()
+		if got != want[td.name] {
+			t.Errorf("%s:\n got %s\nwant %s", td.name, got, want[td.name])
+		}
+	}
+	if got := CreateTable("tags").WithDialect(PostgreSQL).Column("a", Int()).Column("b", Int()).PrimaryKey("a", "b").Query(); got != `CREATE TABLE "tags" ("a" INTEGER, "b" INTEGER, PRIMARY KEY ("a", "b"))` {
+		t.Error(got)
+	}
+}
+
+func TestAlterTable(t *testing.T) {
+	want := map[string][]string{
+		"mysql":     {"ALTER TABLE `users` ADD COLUMN `age` INTEGER NOT NULL DEFAULT 0", "ALTER TABLE `users` DROP COLUMN `bio`", "ALTER TABLE `users` RENAME COLUMN `nick` TO `alias`"},
+		"postgres":  {`ALTER TABLE "users" ADD COLUMN "age" INTEGER NOT NULL DEFAULT 0`, `ALTER TABLE "users" DROP COLUMN "bio"`, `ALTER TABLE "users" RENAME COLUMN "nick" TO "alias"`},
+		"sqlserver": {"ALTER TABLE [users] ADD [age] INT NOT NULL DEFAULT 0", "ALTER TABLE [users] DROP COLUMN [bio]", "EXEC sp_rename N'users.nick', N'alias', 'COLUMN'"},
+	}
+	for _, td := range testDialects {
+		got := AlterTable("users").WithDialect(td.d).AddColumn("age", Int().NotNull().Default(0)).DropColumn("bio").RenameColumn("nick", "alias").Queries()
+		if want[td.name] != nil && !reflect.DeepEqual(got, want[td.name]) {
+			t.Errorf("%s:\n got %q\nwant %q", td.name, got, want[td.name])
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	want := golden{
+		"postgres":  `CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_name" ON "users" ("name", "email")`,
+		"sqlite":    `CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_name" ON "users" ("name", "email")`,
+		"sqlserver": "IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = N'idx_users_name' AND object_id = OBJECT_ID(N'users')) CREATE UNIQUE INDEX [idx_users_name] ON [users] ([name], [email])",
+	}
+	for _, td := range testDialects {
+		got, err := CreateIndex("idx_users_name").On("users", "name", "email").Unique().IfNotExists().WithDialect(td.d).build()
+		if td.d == MySQL {
+			if err == nil {
+				t.Error("mysql: rendered IF NOT EXISTS for an index")
+			}
+			continue
+		}
+		if err != nil || got != want[td.name] {
+			t.Errorf("%s:\n got %s, %v\nwant %s", td.name, got, err, want[td.name])
+		}
+	}
+	drops := golden{
+		"mysql":     "DROP INDEX `idx_users_name` ON `users`",
+		"postgres":  `DROP INDEX "idx_users_name"`,
+		"sqlserver": "DROP INDEX [idx_users_name] ON [users]",
+	}
+	for _, td := range testDialects {
+		if got := DropIndex("idx_users_name").On("users").WithDialect(td.d).Query(); drops[td.name] != "" && got != drops[td.name] {
+			t.Errorf("%s: %s", td.name, got)
+		}
+	}
+	if got := DropTable("users").IfExists().WithDialect(SQLServer).Query(); got != "DROP TABLE IF EXISTS [users]" {
+		t.Error(got)
+	}
+}
+
+func TestDDLRun(t *testing.T) {
+	type wrapped struct{ Dialect }
+	db, f := newFakeDB(t)
+	if err := CreateTable("tags").WithDialect(wrapped{SQLServer}).IfNotExists().Column("id", Int()).Use(db).Run().GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if err := AlterTable("tags").WithDialect(wrapped{SQLServer}).RenameColumn("id", "key").Use(db).Run().GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if err := DropIndex("idx_tags").On("tags").IfExists().WithDialect(MySQL).Use(db).Run().GetError(); err == nil {
+		t.Error("mysql: ran IF EXISTS for an index")
+	}
+	if err := DropTable("tags").Run().GetError(); err != ErrNoExecutor {
+		t.Errorf("no executor: %v", err)
+	}
+	checkStatements(t, f,
+		"IF OBJECT_ID(N'tags', N'U') IS NULL CREATE TABLE [tags] ([id] INT)",
+		"EXEC sp_rename N'tags.id', N'key', 'COLUMN'",
+	)
+}
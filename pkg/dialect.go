@@ -0,0 +1,541 @@
+package gql
+
+import (
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+)
+
+type Dialect interface {
+	Quote(name string) string
+	Placeholder(n int) string
+	String(value string) string
+	Bytes(value []byte) string
+	Bool(value bool) string
+	Now() string
+	Paginate(limit int64, offset int64, ordered bool) (top string, tail string)
+	SupportsLastInsertId() bool
+	SupportsRowValues() bool
+	Upsert(conflict []string, update []string, assign []string, columns []string) (string, error)
+	Returning(typ SqlTyp, columns []string) (output string, tail string, ok bool)
+	Classify(err error) error
+	DataType(kind string, size int, scale int, autoIncrement bool) string
+	Savepoint(name string) string
+	RollbackTo(name string) string
+	Release(name string) string
+	Lock(name string) (acquire string, release string)
+	IfNotExists(kind string, name string, table string, create string) (string, error)
+	AddColumn(table string, column string) string
+	RenameColumn(table string, from string, to string) string
+	DropIndex(name string, table string, ifExists bool) (string, error)
+}
+
+var (
+	MySQL      Dialect = mysqlDialect{}
+	PostgreSQL Dialect = postgresDialect{}
+	SQLite     Dialect = sqliteDialect{}
+	SQLServer  Dialect = sqlserverDialect{}
+)
+
+var defaultDialect = MySQL
+
+func SetDialect(d Dialect) {
+	defaultDialect = d
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.([A-Za-z_][A-Za-z0-9_]*|\*))?$`)
+
+// quoteName quotes plain (optionally table qualified) identifiers and leaves
+// expressions such as "COUNT(*) len" or "users u" untouched.
+func quoteName(d Dialect, name string) string {
+	if !identifier.MatchString(name) {
+		return name
+	}
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if part != "*" {
+			parts[i] = d.Quote(part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Quote(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+func (mysqlDialect) Placeholder(n int) string {
+	return "?"
+}
+func (mysqlDialect) String(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return `'` + strings.ReplaceAll(value, `'`, `\'`) + `'`
+}
+func (mysqlDialect) Bytes(value []byte) string {
+	return "X'" + hex.EncodeToString(value) + "'"
+}
+func (mysqlDialect) Bool(value bool) string {
+	if value {
+		return "true"
+	}
+	return "false"
+}
+
+// NOW(6) keeps the microseconds of DATETIME(6), NOW() isn't a valid default for it.
+func (mysqlDialect) Now() string {
+	return "NOW(6)"
+}
+func (mysqlDialect) Paginate(limit int64, offset int64, ordered bool) (top string, tail string) {
+	if limit > 0 {
+		tail = fmt.Sprintf(" LIMIT %v", limit)
+	} else if offset > 0 {
+		tail = " LIMIT 18446744073709551615"
+	}
+	if offset > 0 {
+		tail += fmt.Sprintf(" OFFSET %v", offset)
+	}
+	return
+}
+
+// MySQL picks the conflicting unique key itself, doing nothing is a no-op assignment.
+func (mysqlDialect) Upsert(conflict []string, update []string, assign []string, columns []string) (string, error) {
+	if update == nil {
+		noop := columns[0]
+		if len(conflict) > 0 {
+			noop = conflict[0]
+		}
+		return " ON DUPLICATE KEY UPDATE " + noop + "=" + noop, nil
+	}
+	sets := make([]string, len(update))
+	for i, column := range update {
+		sets[i] = column + "=VALUES(" + column + ")"
+	}
+	return " ON DUPLICATE KEY UPDATE " + strings.Join(append(sets, assign...), ", "), nil
+}
+func (mysqlDialect) Returning(typ SqlTyp, columns []string) (output string, tail string, ok bool) {
+	return
+}
+func (mysqlDialect) Classify(err error) error {
+	switch number, _ := errorCode(err); number {
+	case 1062:
+		return ErrUniqueViolation
+	case 1213:
+		return ErrDeadlock
+	case 1451, 1452:
+		return ErrForeignKeyViolation
+	}
+	return nil
+}
+func (mysqlDialect) DataType(kind string, size int, scale int, autoIncrement bool) string {
+	out := genericType(kind, size, scale)
+	switch kind {
+	case TypeBool:
+		out = "TINYINT(1)"
+	case TypeFloat:
+		out = "DOUBLE"
+	case TypeBinary:
+		out = "LONGBLOB"
+	case TypeText:
+		out = "LONGTEXT"
+	case TypeTimestamp:
+		out = "DATETIME(6)"
+	}
+	if autoIncrement {
+		out += " AUTO_INCREMENT"
+	}
+	return out
+}
+func (mysqlDialect) Savepoint(name string) string {
+	return "SAVEPOINT " + name
+}
+func (mysqlDialect) RollbackTo(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + name
+}
+func (mysqlDialect) Release(name string) string {
+	return "RELEASE SAVEPOINT " + name
+}
+
+// Lock waits for the session wide lock name, acquire returns a row of 1 once it's held.
+func (d mysqlDialect) Lock(name string) (acquire string, release string) {
+	return "SELECT GET_LOCK(" + d.String(name) + ", -1)", "SELECT RELEASE_LOCK(" + d.String(name) + ")"
+}
+
+// MySQL can't skip an existing index or drop a missing one.
+func (mysqlDialect) IfNotExists(kind string, name string, table string, create string) (string, error) {
+	if kind == "INDEX" {
+		return "", fmt.Errorf("gql: MySQL has no IF EXISTS for indexes")
+	}
+	return ifNotExists(kind, create), nil
+}
+func (d mysqlDialect) AddColumn(table string, column string) string {
+	return "ALTER TABLE " + quoteName(d, table) + " ADD COLUMN " + column
+}
+func (d mysqlDialect) RenameColumn(table string, from string, to string) string {
+	return renameColumn(d, table, from, to)
+}
+func (d mysqlDialect) DropIndex(name string, table string, ifExists bool) (string, error) {
+	if ifExists {
+		return "", fmt.Errorf("gql: MySQL has no IF EXISTS for indexes")
+	}
+	return dropIndex(d, name, table, false), nil
+}
+func (mysqlDialect) SupportsLastInsertId() bool {
+	return true
+}
+func (mysqlDialect) SupportsRowValues() bool {
+	return true
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+func (postgresDialect) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+func (postgresDialect) String(value string) string {
+	return `'` + strings.ReplaceAll(value, `'`, `''`) + `'`
+}
+func (postgresDialect) Bytes(value []byte) string {
+	return `'\x` + hex.EncodeToString(value) + `'::bytea`
+}
+func (postgresDialect) Bool(value bool) string {
+	if value {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+func (postgresDialect) Now() string {
+	return "NOW()"
+}
+func (postgresDialect) Paginate(limit int64, offset int64, ordered bool) (top string, tail string) {
+	if limit > 0 {
+		tail = fmt.Sprintf(" LIMIT %v", limit)
+	}
+	if offset > 0 {
+		tail += fmt.Sprintf(" OFFSET %v", offset)
+	}
+	return
+}
+func (postgresDialect) Upsert(conflict []string, update []string, assign []string, columns []string) (string, error) {
+	return onConflict(conflict, update, assign)
+}
+func (postgresDialect) Returning(typ SqlTyp, columns []string) (output string, tail string, ok bool) {
+	return "", " RETURNING " + strings.Join(columns, ", "), true
+}
+func (postgresDialect) Classify(err error) error {
+	switch _, state := errorCode(err); state {
+	case "23505":
+		return ErrUniqueViolation
+	case "40P01":
+		return ErrDeadlock
+	case "23503":
+		return ErrForeignKeyViolation
+	}
+	return nil
+}
+func (postgresDialect) DataType(kind string, size int, scale int, autoIncrement bool) string {
+	if autoIncrement {
+		switch kind {
+		case TypeSmallInt:
+			return "SMALLSERIAL"
+		case TypeInt:
+			return "SERIAL"
+		}
+		return "BIGSERIAL"
+	}
+	switch kind {
+	case TypeFloat:
+		return "DOUBLE PRECISION"
+	case TypeDecimal:
+		return strings.Replace(genericType(kind, size, scale), "DECIMAL", "NUMERIC", 1)
+	case TypeBinary:
+		return "BYTEA"
+	}
+	return genericType(kind, size, scale)
+}
+func (postgresDialect) Savepoint(name string) string {
+	return "SAVEPOINT " + name
+}
+func (postgresDialect) RollbackTo(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + name
+}
+func (postgresDialect) Release(name string) string {
+	return "RELEASE SAVEPOINT " + name
+}
+
+// Advisory locks are keyed by a number, the name is hashed into one.
+func (postgresDialect) Lock(name string) (acquire string, release string) {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	key := int64(h.Sum64())
+	return fmt.Sprintf("SELECT 1 FROM pg_advisory_lock(%d)", key), fmt.Sprintf("SELECT pg_advisory_unlock(%d)", key)
+}
+func (postgresDialect) IfNotExists(kind string, name string, table string, create string) (string, error) {
+	return ifNotExists(kind, create), nil
+}
+func (d postgresDialect) AddColumn(table string, column string) string {
+	return "ALTER TABLE " + quoteName(d, table) + " ADD COLUMN " + column
+}
+func (d postgresDialect) RenameColumn(table string, from string, to string) string {
+	return renameColumn(d, table, from, to)
+}
+func (d postgresDialect) DropIndex(name string, table string, ifExists bool) (string, error) {
+	return dropIndex(d, name, "", ifExists), nil
+}
+func (postgresDialect) SupportsLastInsertId() bool {
+	return false
+}
+func (postgresDialect) SupportsRowValues() bool {
+	return true
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+func (sqliteDialect) Placeholder(n int) string {
+	return "?"
+}
+func (sqliteDialect) String(value string) string {
+	return `'` + strings.ReplaceAll(value, `'`, `''`) + `'`
+}
+func (sqliteDialect) Bytes(value []byte) string {
+	return "X'" + hex.EncodeToString(value) + "'"
+}
+func (sqliteDialect) Bool(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+func (sqliteDialect) Now() string {
+	return "CURRENT_TIMESTAMP"
+}
+func (sqliteDialect) Paginate(limit int64, offset int64, ordered bool) (top string, tail string) {
+	if limit > 0 {
+		tail = fmt.Sprintf(" LIMIT %v", limit)
+	} else if offset > 0 {
+		tail = " LIMIT -1"
+	}
+	if offset > 0 {
+		tail += fmt.Sprintf(" OFFSET %v", offset)
+	}
+	return
+}
+func (sqliteDialect) Upsert(conflict []string, update []string, assign []string, columns []string) (string, error) {
+	return onConflict(conflict, update, assign)
+}
+func (sqliteDialect) Returning(typ SqlTyp, columns []string) (output string, tail string, ok bool) {
+	return "", " RETURNING " + strings.Join(columns, ", "), true
+}
+func (sqliteDialect) Classify(err error) error {
+	switch number, _ := errorCode(err); number {
+	case 1555, 2067, 2579:
+		return ErrUniqueViolation
+	case 787:
+		return ErrForeignKeyViolation
+	}
+	return nil
+}
+
+// DataType maps every integer to INTEGER, an auto increment column must also be the primary key to
+// become the rowid.
+func (sqliteDialect) DataType(kind string, size int, scale int, autoIncrement bool) string {
+	switch kind {
+	case TypeSmallInt, TypeInt, TypeBigInt:
+		return "INTEGER"
+	case TypeFloat:
+		return "REAL"
+	case TypeBinary:
+		return "BLOB"
+	}
+	return genericType(kind, size, scale)
+}
+func (sqliteDialect) Savepoint(name string) string {
+	return "SAVEPOINT " + name
+}
+func (sqliteDialect) RollbackTo(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + name
+}
+func (sqliteDialect) Release(name string) string {
+	return "RELEASE SAVEPOINT " + name
+}
+
+// SQLite has no named locks, it lets one writer at a time in so a competing transaction fails instead.
+func (sqliteDialect) Lock(name string) (acquire string, release string) {
+	return "", ""
+}
+func (sqliteDialect) IfNotExists(kind string, name string, table string, create string) (string, error) {
+	return ifNotExists(kind, create), nil
+}
+func (d sqliteDialect) AddColumn(table string, column string) string {
+	return "ALTER TABLE " + quoteName(d, table) + " ADD COLUMN " + column
+}
+func (d sqliteDialect) RenameColumn(table string, from string, to string) string {
+	return renameColumn(d, table, from, to)
+}
+func (d sqliteDialect) DropIndex(name string, table string, ifExists bool) (string, error) {
+	return dropIndex(d, name, "", ifExists), nil
+}
+func (sqliteDialect) SupportsLastInsertId() bool {
+	return true
+}
+func (sqliteDialect) SupportsRowValues() bool {
+	return true
+}
+
+type sqlserverDialect struct{}
+
+func (sqlserverDialect) Quote(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+func (sqlserverDialect) Placeholder(n int) string {
+	return fmt.Sprintf("@p%d", n)
+}
+func (sqlserverDialect) String(value string) string {
+	return `N'` + strings.ReplaceAll(value, `'`, `''`) + `'`
+}
+func (sqlserverDialect) Bytes(value []byte) string {
+	return "0x" + hex.EncodeToString(value)
+}
+func (sqlserverDialect) Bool(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+func (sqlserverDialect) Now() string {
+	return "GETDATE()"
+}
+
+// OFFSET ... FETCH is only valid after ORDER BY, plain limits use TOP.
+func (sqlserverDialect) Paginate(limit int64, offset int64, ordered bool) (top string, tail string) {
+	if offset <= 0 {
+		if limit > 0 {
+			top = fmt.Sprintf("TOP %v ", limit)
+		}
+		return
+	}
+	if !ordered {
+		tail = " ORDER BY (SELECT NULL)"
+	}
+	tail += fmt.Sprintf(" OFFSET %v ROWS", offset)
+	if limit > 0 {
+		tail += fmt.Sprintf(" FETCH NEXT %v ROWS ONLY", limit)
+	}
+	return
+}
+func (sqlserverDialect) Upsert(conflict []string, update []string, assign []string, columns []string) (string, error) {
+	return "", fmt.Errorf("gql: upsert is not supported by SQL Server, use MERGE through Custom()")
+}
+func (sqlserverDialect) Returning(typ SqlTyp, columns []string) (output string, tail string, ok bool) {
+	prefix := "INSERTED."
+	if typ == SqlTypDelete {
+		prefix = "DELETED."
+	}
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = prefix + column
+	}
+	return " OUTPUT " + strings.Join(names, ", "), "", true
+}
+func (sqlserverDialect) Classify(err error) error {
+	switch number, _ := errorCode(err); number {
+	case 2627, 2601:
+		return ErrUniqueViolation
+	case 1205:
+		return ErrDeadlock
+	case 547:
+		return ErrForeignKeyViolation
+	}
+	return nil
+}
+func (sqlserverDialect) DataType(kind string, size int, scale int, autoIncrement bool) string {
+	out := genericType(kind, size, scale)
+	switch kind {
+	case TypeBool:
+		out = "BIT"
+	case TypeInt:
+		out = "INT"
+	case TypeFloat:
+		out = "FLOAT"
+	case TypeVarchar:
+		out = "N" + out
+	case TypeText:
+		out = "NVARCHAR(MAX)"
+	case TypeBinary:
+		out = "VARBINARY(MAX)"
+	case TypeTimestamp:
+		out = "DATETIME2"
+	}
+	if autoIncrement {
+		out += " IDENTITY(1,1)"
+	}
+	return out
+}
+func (sqlserverDialect) Savepoint(name string) string {
+	return "SAVE TRANSACTION " + name
+}
+func (sqlserverDialect) RollbackTo(name string) string {
+	return "ROLLBACK TRANSACTION " + name
+}
+
+// SQL Server has no way to release a savepoint, it lives until the transaction ends.
+func (sqlserverDialect) Release(name string) string {
+	return ""
+}
+func (d sqlserverDialect) Lock(name string) (acquire string, release string) {
+	acquire = "DECLARE @r int; EXEC @r = sp_getapplock @Resource = " + d.String(name) +
+		", @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = -1; SELECT CASE WHEN @r >= 0 THEN 1 ELSE 0 END"
+	release = "EXEC sp_releaseapplock @Resource = " + d.String(name) + ", @LockOwner = 'Session'"
+	return
+}
+
+// SQL Server has no IF NOT EXISTS, the statement runs only when the catalog lacks the object.
+func (d sqlserverDialect) IfNotExists(kind string, name string, table string, create string) (string, error) {
+	if kind == "INDEX" {
+		return "IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = " + d.String(name) + " AND object_id = OBJECT_ID(" + d.String(table) + ")) " + create, nil
+	}
+	return "IF OBJECT_ID(" + d.String(name) + ", N'U') IS NULL " + create, nil
+}
+func (d sqlserverDialect) AddColumn(table string, column string) string {
+	return "ALTER TABLE " + quoteName(d, table) + " ADD " + column
+}
+func (d sqlserverDialect) RenameColumn(table string, from string, to string) string {
+	return "EXEC sp_rename " + d.String(table+"."+from) + ", " + d.String(to) + ", 'COLUMN'"
+}
+func (d sqlserverDialect) DropIndex(name string, table string, ifExists bool) (string, error) {
+	return dropIndex(d, name, table, ifExists), nil
+}
+func (sqlserverDialect) SupportsLastInsertId() bool {
+	return false
+}
+
+// SQL Server can't compare row values, (a, b) > (x, y) is expanded into ORs.
+func (sqlserverDialect) SupportsRowValues() bool {
+	return false
+}
+
+func onConflict(conflict []string, update []string, assign []string) (string, error) {
+	target := ""
+	if len(conflict) > 0 {
+		target = " (" + strings.Join(conflict, ", ") + ")"
+	}
+	if update == nil {
+		return " ON CONFLICT" + target + " DO NOTHING", nil
+	}
+	if target == "" {
+		return "", fmt.Errorf("gql: DoUpdate needs the conflicting columns passed to OnConflict")
+	}
+	sets := make([]string, len(update))
+	for i, column := range update {
+		sets[i] = column + "=EXCLUDED." + column
+	}
+	return " ON CONFLICT" + target + " DO UPDATE SET " + strings.Join(append(sets, assign...), ", "), nil
+}
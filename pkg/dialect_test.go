@@ -0,0 +1,127 @@
+package gql
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var testDialects = []struct {
+	name string
+	d    Dialect
+}{
+	{"mysql", MySQL},
+	{"postgres", PostgreSQL},
+	{"sqlite", SQLite},
+	{"sqlserver", SQLServer},
+}
+
+// golden is the SQL a statement renders per dialect, dialects left out aren't checked.
+type golden map[string]string
+
+// checkSQL renders a fresh builder per dialect of want and compares the SQL and the bound arguments.
+func checkSQL(t *testing.T, build func() Builder, want golden, args ...interface{}) {
+	t.Helper()
+	for _, td := range testDialects {
+		sql, ok := want[td.name]
+		if !ok {
+			continue
+		}
+		b := build().WithDialect(td.d)
+		got, gotArgs := b.QueryWithArgs()
+		if err := b.GetError(); err != nil {
+			t.Errorf("%s: %v", td.name, err)
+			continue
+		}
+		if got != sql {
+			t.Errorf("%s:\n got %s\nwant %s", td.name, got, sql)
+		}
+		if (len(args) > 0 || len(gotArgs) > 0) && !reflect.DeepEqual(gotArgs, args) {
+			t.Errorf("%s: args %#v, want %#v", td.name, gotArgs, args)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	checkSQL(t, func() Builder {
+		return Read("users u").Columns("u.name", Count("*", "n")).Where("u.age", 3).WhereIn("u.id", []interface{}{1, 2}).
+			GroupBy("u.name").OrderBy("u.name").Offset(20).Top(10)
+	}, golden{
+		"mysql":     "SELECT `u`.`name`, COUNT(*) n FROM users u WHERE `u`.`age` = ? AND `u`.`id` in (?,?) GROUP BY `u`.`name` ORDER BY `u`.`name` ASC LIMIT 10 OFFSET 20",
+		"postgres":  `SELECT "u"."name", COUNT(*) n FROM users u WHERE "u"."age" = $1 AND "u"."id" in ($2,$3) GROUP BY "u"."name" ORDER BY "u"."name" ASC LIMIT 10 OFFSET 20`,
+		"sqlite":    `SELECT "u"."name", COUNT(*) n FROM users u WHERE "u"."age" = ? AND "u"."id" in (?,?) GROUP BY "u"."name" ORDER BY "u"."name" ASC LIMIT 10 OFFSET 20`,
+		"sqlserver": "SELECT [u].[name], COUNT(*) n FROM users u WHERE [u].[age] = @p1 AND [u].[id] in (@p2,@p3) GROUP BY [u].[name] ORDER BY [u].[name] ASC OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY",
+	}, 3, 1, 2)
+}
+
+func TestPaginate(t *testing.T) {
+	checkSQL(t, func() Builder { return Read("users").Top(5) }, golden{
+		"mysql":     "SELECT * FROM `users` LIMIT 5",
+		"postgres":  `SELECT * FROM "users" LIMIT 5`,
+		"sqlite":    `SELECT * FROM "users" LIMIT 5`,
+		"sqlserver": "SELECT TOP 5 * FROM [users]",
+	})
+	checkSQL(t, func() Builder { return Read("users").Offset(5) }, golden{
+		"mysql":     "SELECT * FROM `users` LIMIT 18446744073709551615 OFFSET 5",
+		"postgres":  `SELECT * FROM "users" OFFSET 5`,
+		"sqlite":    `SELECT * FROM "users" LIMIT -1 OFFSET 5`,
+		"sqlserver": "SELECT * FROM [users] ORDER BY (SELECT NULL) OFFSET 5 ROWS",
+	})
+}
+
+func TestWrite(t *testing.T) {
+	checkSQL(t, func() Builder { return Create("users").Set("name", "a").Set("age", 3) }, golden{
+		"mysql":     "INSERT INTO `users`(`name`, `age`) VALUES(?, ?)",
+		"postgres":  `INSERT INTO "users"("name", "age") VALUES($1, $2)`,
+		"sqlite":    `INSERT INTO "users"("name", "age") VALUES(?, ?)`,
+		"sqlserver": "INSERT INTO [users]([name], [age]) VALUES(@p1, @p2)",
+	}, "a", 3)
+	checkSQL(t, func() Builder {
+		return Create("users").Fill(&OBJ{"name": "a", "age": 1}, &OBJ{"name": "b", "age": 2})
+	}, golden{
+		"mysql":     "INSERT INTO `users`(`age`, `name`) VALUES(?, ?), (?, ?)",
+		"postgres":  `INSERT INTO "users"("age", "name") VALUES($1, $2), ($3, $4)`,
+		"sqlserver": "INSERT INTO [users]([age], [name]) VALUES(@p1, @p2), (@p3, @p4)",
+	}, 1, "a", 2, "b")
+	checkSQL(t, func() Builder { return Update("users").Set("name", "a").Where("id", 1) }, golden{
+		"mysql":     "UPDATE `users` SET `name`=? WHERE `id` = ?",
+		"postgres":  `UPDATE "users" SET "name"=$1 WHERE "id" = $2`,
+		"sqlserver": "UPDATE [users] SET [name]=@p1 WHERE [id] = @p2",
+	}, "a", 1)
+	checkSQL(t, func() Builder { return Delete("users").Where("id", 1) }, golden{
+		"mysql":     "DELETE FROM `users` WHERE `id` = ?",
+		"postgres":  `DELETE FROM "users" WHERE "id" = $1`,
+		"sqlserver": "DELETE FROM [users] WHERE [id] = @p1",
+	}, 1)
+}
+
+func TestColumnMismatch(t *testing.T) {
+	b := Create("users").Fill(&OBJ{"a": 1}, &OBJ{"b": 2})
+	if q, _ := b.QueryWithArgs(); q != "" || !errors.Is(b.GetError(), ErrColumnMismatch) {
+		t.Errorf("%q, %v", q, b.GetError())
+	}
+}
+
+func TestMissingWhere(t *testing.T) {
+	for _, b := range []Builder{Update("users").Set("name", "a"), Delete("users")} {
+		if q, _ := b.QueryWithArgs(); q != "" || b.GetError() != ErrMissingWhere {
+			t.Errorf("%q, %v", q, b.GetError())
+		}
+	}
+	checkSQL(t, func() Builder { return Delete("users").Unsafe() }, golden{"mysql": "DELETE FROM `users`"})
+}
+
+func TestInline(t *testing.T) {
+	want := golden{
+		"mysql":     "SELECT * FROM `users` WHERE `name` = 'o\\'k\\\\' AND `data` = X'0102' AND `on` = true AND `at` = NOW(6)",
+		"postgres":  `SELECT * FROM "users" WHERE "name" = 'o''k\' AND "data" = '\x0102'::bytea AND "on" = TRUE AND "at" = NOW()`,
+		"sqlite":    `SELECT * FROM "users" WHERE "name" = 'o''k\' AND "data" = X'0102' AND "on" = 1 AND "at" = CURRENT_TIMESTAMP`,
+		"sqlserver": `SELECT * FROM [users] WHERE [name] = N'o''k\' AND [data] = 0x0102 AND [on] = 1 AND [at] = GETDATE()`,
+	}
+	for _, td := range testDialects {
+		got := Read("users").WithDialect(td.d).Where("name", `o'k\`).Where("data", []byte{1, 2}).Where("on", true).Where("at", Now()).Query()
+		if got != want[td.name] {
+			t.Errorf("%s:\n got %s\nwant %s", td.name, got, want[td.name])
+		}
+	}
+}
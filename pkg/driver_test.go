@@ -0,0 +1,144 @@
+package gql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDB is a database/sql driver answering statements from a script, it records what it's sent.
+type fakeDB struct {
+	mu      sync.Mutex
+	log     []string
+	args    [][]driver.Value
+	answers []fakeAnswer
+}
+
+// fakeAnswer is the result of the next statement starting with prefix, each answer is used once.
+type fakeAnswer struct {
+	prefix   string
+	columns  []string
+	rows     [][]driver.Value
+	id       int64
+	affected int64
+	err      error
+}
+
+func newFakeDB(t *testing.T, answers ...fakeAnswer) (*sql.DB, *fakeDB) {
+	f := &fakeDB{answers: answers}
+	db := sql.OpenDB(f)
+	t.Cleanup(func() { db.Close() })
+	return db, f
+}
+
+// statements returns what was sent so far, transaction boundaries as BEGIN, COMMIT and ROLLBACK.
+func (f *fakeDB) statements() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.log...)
+}
+
+func (f *fakeDB) run(query string, args []driver.NamedValue) fakeAnswer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	f.log = append(f.log, query)
+	f.args = append(f.args, values)
+	for i, a := range f.answers {
+		if strings.HasPrefix(query, a.prefix) {
+			f.answers = append(f.answers[:i:i], f.answers[i+1:]...)
+			return a
+		}
+	}
+	return fakeAnswer{}
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+
+func (f *fakeDB) Driver() driver.Driver { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return nil, errors.New("fake: use sql.OpenDB") }
+
+type fakeConn struct{ f *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("fake: no prepare") }
+
+func (c fakeConn) Close() error { return nil }
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.f.run("BEGIN", nil)
+	return c, nil
+}
+
+func (c fakeConn) Commit() error {
+	c.f.run("COMMIT", nil)
+	return nil
+}
+
+func (c fakeConn) Rollback() error {
+	c.f.run("ROLLBACK", nil)
+	return nil
+}
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	a := c.f.run(query, args)
+	if a.err != nil {
+		return nil, a.err
+	}
+	return &fakeRows{columns: a.columns, rows: a.rows}, nil
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	a := c.f.run(query, args)
+	if a.err != nil {
+		return nil, a.err
+	}
+	return fakeResult(a), nil
+}
+
+type fakeResult fakeAnswer
+
+func (r fakeResult) LastInsertId() (int64, error) { return r.id, nil }
+
+func (r fakeResult) RowsAffected() (int64, error) { return r.affected, nil }
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// checkStatements compares what f was sent with want.
+func checkStatements(t *testing.T, f *fakeDB, want ...string) {
+	t.Helper()
+	got := f.statements()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("statements:\n got %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,104 @@
+package gql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	ErrNoExecutor     = errors.New("gql: no executor, call Use() before running the query")
+	ErrColumnMismatch = errors.New("gql: rows of a bulk insert have different columns")
+	ErrMissingWhere   = errors.New("gql: update or delete without a where clause, call Unsafe() to affect every row")
+	ErrStaleObject    = errors.New("gql: the row was changed or removed since it was read")
+	ErrNoRows         = errors.New("gql: no rows found")
+	ErrInvalidTarget  = errors.New("gql: invalid target")
+	ErrNoReturning    = errors.New("gql: the dialect can't return the written rows")
+
+	// driver errors are classified into these by the dialect, match them with errors.Is
+	ErrUniqueViolation     = errors.New("gql: unique constraint violation")
+	ErrForeignKeyViolation = errors.New("gql: foreign key violation")
+	ErrDeadlock            = errors.New("gql: deadlock")
+)
+
+// QueryError wraps an error of the driver with the statement that caused it.
+type QueryError struct {
+	SQL  string
+	Args []interface{}
+	Err  error
+	kind error
+}
+
+func (e *QueryError) Error() string {
+	return e.Err.Error() + ": " + e.SQL
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the classification of the driver error, e.g. ErrUniqueViolation.
+func (e *QueryError) Is(target error) bool {
+	return e.kind != nil && e.kind == target
+}
+
+func (b *QueryBuilder) queryError(query string, args []interface{}, err error) error {
+	return newQueryError(b.getDialect(), query, args, err)
+}
+
+func newQueryError(d Dialect, query string, args []interface{}, err error) error {
+	return &QueryError{SQL: query, Args: args, Err: err, kind: d.Classify(err)}
+}
+
+// errorCode reads the vendor code of a driver error without importing the driver: the Number or
+// ExtendedCode field (MySQL, SQL Server, SQLite), the Code field or the SQLState method (Postgres).
+func errorCode(err error) (number int64, state string) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if s, ok := err.(interface{ SQLState() string }); ok {
+			state = s.SQLState()
+		}
+		if c, ok := err.(interface{ Code() int }); ok {
+			number = int64(c.Code())
+		}
+		if v := reflect.Indirect(reflect.ValueOf(err)); v.Kind() == reflect.Struct {
+			for _, name := range []string{"ExtendedCode", "Number", "Code"} {
+				f := v.FieldByName(name)
+				switch f.Kind() {
+				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+					number = f.Int()
+				case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+					number = int64(f.Uint())
+				case reflect.String:
+					state = f.String()
+				default:
+					continue
+				}
+				break
+			}
+		}
+		if number != 0 || state != "" {
+			return
+		}
+	}
+	return
+}
+
+// checkTarget accepts a non nil pointer to a struct, to a pointer to one or to a slice of either.
+func checkTarget(o interface{}, alloc bool) error {
+	v := reflect.ValueOf(o)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		t := v.Type().Elem()
+		if t.Kind() == reflect.Slice {
+			t = t.Elem()
+		} else if t.Kind() == reflect.Ptr && !alloc && v.Elem().IsNil() {
+			return fmt.Errorf("%w: %T points to nil", ErrInvalidTarget, o)
+		}
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %T, expected a pointer to a struct or to a slice of structs", ErrInvalidTarget, o)
+}
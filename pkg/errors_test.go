@@ -0,0 +1,54 @@
+package gql
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type numberError struct{ Number int }
+
+func (e numberError) Error() string { return fmt.Sprint("error ", e.Number) }
+
+type stateError struct{ Code string }
+
+func (e *stateError) Error() string { return "error " + e.Code }
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		d    Dialect
+		err  error
+		kind error
+	}{
+		{MySQL, numberError{1062}, ErrUniqueViolation},
+		{MySQL, numberError{1213}, ErrDeadlock},
+		{MySQL, numberError{1452}, ErrForeignKeyViolation},
+		{PostgreSQL, &stateError{"23505"}, ErrUniqueViolation},
+		{PostgreSQL, &stateError{"40P01"}, ErrDeadlock},
+		{PostgreSQL, &stateError{"23503"}, ErrForeignKeyViolation},
+		{SQLServer, numberError{2627}, ErrUniqueViolation},
+		{SQLServer, numberError{1205}, ErrDeadlock},
+		{SQLServer, numberError{547}, ErrForeignKeyViolation},
+		{MySQL, numberError{1}, nil},
+	}
+	for _, c := range cases {
+		err := newQueryError(c.d, "SELECT 1", nil, fmt.Errorf("wrapped: %w", c.err))
+		if c.kind != nil && !errors.Is(err, c.kind) || c.kind == nil && (errors.Is(err, ErrUniqueViolation) || errors.Is(err, ErrDeadlock)) {
+			t.Errorf("%T %v: not classified as %v", c.d, c.err, c.kind)
+		}
+		var qe *QueryError
+		if !errors.As(err, &qe) || qe.SQL != "SELECT 1" || !errors.Is(err, c.err) {
+			t.Errorf("%T %v: lost the query or the driver error", c.d, c.err)
+		}
+	}
+}
+
+func TestCheckTarget(t *testing.T) {
+	var u typedUser
+	var p *typedUser
+	for target, ok := range map[interface{}]bool{&u: true, &[]typedUser{}: true, &[]*typedUser{}: true, &p: false, u: false, new(int): false} {
+		if err := checkTarget(target, false); (err == nil) != ok || err != nil && !errors.Is(err, ErrInvalidTarget) {
+			t.Errorf("%T: %v", target, err)
+		}
+	}
+}
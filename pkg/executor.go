@@ -0,0 +1,20 @@
+package gql
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Executor is anything queries can be run against. Only the context aware methods are required
+// since *sql.Conn has no Exec/Query without a context; builders without WithContext use context.Background().
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+var (
+	_ Executor = (*sql.DB)(nil)
+	_ Executor = (*sql.Tx)(nil)
+	_ Executor = (*sql.Conn)(nil)
+	_ Executor = (*Tx)(nil)
+)
@@ -0,0 +1,125 @@
+package gql
+
+import (
+	"context"
+	"reflect"
+)
+
+// Models implement any of these on their pointer receiver to run logic around persistence, an error
+// aborts the statement and is returned by GetError. The hooks run from Run with the context of the
+// builder, the values a before hook changes are the ones written.
+type BeforeCreator interface {
+	BeforeCreate(ctx context.Context) error
+}
+
+type AfterCreator interface {
+	AfterCreate(ctx context.Context) error
+}
+
+type BeforeUpdater interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+type AfterUpdater interface {
+	AfterUpdate(ctx context.Context) error
+}
+
+// BeforeDeleter runs on the bound struct or slice of a delete, or without one on every row of the model
+// the delete matches, read beforehand.
+type BeforeDeleter interface {
+	BeforeDelete(ctx context.Context) error
+}
+
+type AfterFinder interface {
+	AfterFind(ctx context.Context) error
+}
+
+// eachRow calls fn with a pointer to the bound struct or to every element of the bound slice.
+func eachRow(v reflect.Value, fn func(ifc interface{}) error) error {
+	v = reflect.Indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() == reflect.Slice {
+		for i := 0; i < v.Len(); i++ {
+			if err := eachRow(v.Index(i), fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if v.Kind() != reflect.Struct || !v.CanAddr() {
+		return nil
+	}
+	return fn(v.Addr().Interface())
+}
+
+func (b *QueryBuilder) beforeHooks() error {
+	if b.obj == nil {
+		return b.beforeDelete()
+	}
+	ctx := b.getContext()
+	return eachRow(reflect.ValueOf(b.obj), func(ifc interface{}) error {
+		switch b.typ {
+		case SqlTypCreate:
+			if h, ok := ifc.(BeforeCreator); ok {
+				return h.BeforeCreate(ctx)
+			}
+		case SqlTypUpdate:
+			if h, ok := ifc.(BeforeUpdater); ok {
+				return h.BeforeUpdate(ctx)
+			}
+		case SqlTypDelete:
+			if h, ok := ifc.(BeforeDeleter); ok {
+				return h.BeforeDelete(ctx)
+			}
+		}
+		return nil
+	})
+}
+
+// beforeDelete reads the rows a delete of a model matches and calls their BeforeDelete.
+func (b *QueryBuilder) beforeDelete() error {
+	if b.typ != SqlTypDelete || b.model == nil || !reflect.PointerTo(b.model.Type).Implements(reflect.TypeOf((*BeforeDeleter)(nil)).Elem()) {
+		return nil
+	}
+	r := b.selectFrom()
+	r.wheres = b.wheres
+	r.ops = b.ops
+	r.trashed = b.trashed
+	rows := reflect.New(reflect.SliceOf(b.model.Type))
+	if err := r.Scan(rows.Interface()).GetError(); err != nil {
+		return err
+	}
+	ctx := b.getContext()
+	return eachRow(rows, func(ifc interface{}) error {
+		return ifc.(BeforeDeleter).BeforeDelete(ctx)
+	})
+}
+
+func (b *QueryBuilder) afterHooks() error {
+	if b.obj == nil {
+		return nil
+	}
+	ctx := b.getContext()
+	return eachRow(reflect.ValueOf(b.obj), func(ifc interface{}) error {
+		switch b.typ {
+		case SqlTypCreate:
+			if h, ok := ifc.(AfterCreator); ok {
+				return h.AfterCreate(ctx)
+			}
+		case SqlTypUpdate:
+			if h, ok := ifc.(AfterUpdater); ok {
+				return h.AfterUpdate(ctx)
+			}
+		}
+		return nil
+	})
+}
+
+func afterFind(ctx context.Context, ifc interface{}) error {
+	if h, ok := ifc.(AfterFinder); ok {
+		return h.AfterFind(ctx)
+	}
+	return nil
+}
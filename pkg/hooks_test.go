@@ -0,0 +1,110 @@
+package gql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type hookKey struct{}
+
+type hooked struct {
+	ID    int64  `gql:"id"`
+	Name  string `gql:"name"`
+	calls []string
+}
+
+func (h *hooked) record(ctx context.Context, hook string) error {
+	value, _ := ctx.Value(hookKey{}).(string)
+	h.calls = append(h.calls, hook+":"+value)
+	if strings.HasPrefix(hook, "Before") && strings.EqualFold(h.Name, "fail") {
+		return errors.New(hook + " failed")
+	}
+	return nil
+}
+
+func (h *hooked) BeforeCreate(ctx context.Context) error {
+	h.Name = strings.ToUpper(h.Name)
+	return h.record(ctx, "BeforeCreate")
+}
+
+func (h *hooked) AfterCreate(ctx context.Context) error { return h.record(ctx, "AfterCreate") }
+
+func (h *hooked) BeforeUpdate(ctx context.Context) error {
+	h.Name = strings.ToUpper(h.Name)
+	return h.record(ctx, "BeforeUpdate")
+}
+
+func (h *hooked) AfterUpdate(ctx context.Context) error { return h.record(ctx, "AfterUpdate") }
+
+func (h *hooked) BeforeDelete(ctx context.Context) error { return h.record(ctx, "BeforeDelete") }
+
+func (h *hooked) AfterFind(ctx context.Context) error { return h.record(ctx, "AfterFind") }
+
+func TestHooks(t *testing.T) {
+	ctx := context.WithValue(context.Background(), hookKey{}, "req")
+	db, f := newFakeDB(t)
+
+	h := hooked{Name: "a"}
+	b := Create("").WithDialect(PostgreSQL).Bind(&h)
+	b.QueryWithArgs()
+	if len(h.calls) > 0 {
+		t.Errorf("rendering ran %v", h.calls)
+	}
+	if err := b.Use(db).WithContext(ctx).Run().GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(h.calls, []string{"BeforeCreate:req", "AfterCreate:req"}) {
+		t.Errorf("create ran %v", h.calls)
+	}
+
+	h = hooked{ID: 1, Name: "b"}
+	err := Update("").WithDialect(PostgreSQL).Bind(&h).Set("id", 2).Where("id", 1).Use(db).WithContext(ctx).Run().GetError()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(h.calls, []string{"BeforeUpdate:req", "AfterUpdate:req"}) {
+		t.Errorf("update ran %v", h.calls)
+	}
+
+	h = hooked{Name: "c"}
+	if err = InsertContext(ctx, db, &h); err != nil || len(h.calls) != 2 || h.calls[0] != "BeforeCreate:req" {
+		t.Errorf("%v, %v", h.calls, err)
+	}
+
+	h = hooked{Name: "fail"}
+	if err = Update("").Bind(&h).Where("id", 1).Use(db).Run().GetError(); err == nil || err.Error() != "BeforeUpdate failed" {
+		t.Errorf("hook error: %v", err)
+	}
+
+	checkStatements(t, f,
+		`INSERT INTO "hookeds"("name") VALUES($1) RETURNING "id"`,
+		`UPDATE "hookeds" SET "name"=$1, "id"=$2 WHERE "id" = $3`,
+		"INSERT INTO `hookeds`(`name`) VALUES(?)",
+	)
+	if want := [][]driver.Value{{"A"}, {"B", int64(2), int64(1)}, {"C"}}; !reflect.DeepEqual(f.args, want) {
+		t.Errorf("args %v, want %v", f.args, want)
+	}
+}
+
+func TestDeleteHooks(t *testing.T) {
+	ctx := context.WithValue(context.Background(), hookKey{}, "req")
+	db, f := newFakeDB(t, fakeAnswer{columns: []string{"id", "name"}, rows: [][]driver.Value{{1, "a"}, {2, "fail"}}})
+	err := Delete("").Model(&hooked{}).Where("id", 1).Use(db).WithContext(ctx).Run().GetError()
+	if err == nil || err.Error() != "BeforeDelete failed" {
+		t.Errorf("hook error: %v", err)
+	}
+	checkStatements(t, f, "SELECT * FROM `hookeds` WHERE `id` = ?")
+
+	db, _ = newFakeDB(t, fakeAnswer{columns: []string{"id", "name"}, rows: [][]driver.Value{{1, "a"}}})
+	var rows []hooked
+	if err = Read("").Model(&hooked{}).Use(db).WithContext(ctx).Scan(&rows).GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || !reflect.DeepEqual(rows[0].calls, []string{"AfterFind:req"}) {
+		t.Errorf("%+v", rows)
+	}
+}
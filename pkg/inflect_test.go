@@ -0,0 +1,23 @@
+package gql
+
+import "testing"
+
+func TestInflect(t *testing.T) {
+	for singular, plural := range map[string]string{
+		"user": "users", "category": "categories", "key": "keys", "address": "addresses",
+		"box": "boxes", "church": "churches", "wish": "wishes", "order_item": "order_items", "OrderItem": "OrderItems",
+	} {
+		if got := Plural(singular); got != plural {
+			t.Errorf("Plural(%s) = %s, want %s", singular, got, plural)
+		}
+		if got := Singular(plural); got != singular {
+			t.Errorf("Singular(%s) = %s, want %s", plural, got, singular)
+		}
+	}
+	type Category struct {
+		ID int64 `gql:"id"`
+	}
+	if m, _ := GetModel(&Category{}); m.Table != "categories" {
+		t.Errorf("table %s", m.Table)
+	}
+}
@@ -0,0 +1,138 @@
+package gql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+type TableInfo struct {
+	Name    string
+	Columns []ColumnInfo
+}
+
+type ColumnInfo struct {
+	Name          string
+	Type          string
+	Nullable      bool
+	PrimaryKey    bool
+	AutoIncrement bool
+}
+
+// catalog queries the schema of a dialect: the tables of the current schema, the columns of a table as
+// name, type, nullable, primary key and auto increment, and the index names of a table.
+type catalog interface {
+	catalog() (tables string, columns string, indexes string)
+}
+
+func (mysqlDialect) catalog() (string, string, string) {
+	return "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE' ORDER BY table_name",
+		"SELECT column_name, column_type, is_nullable = 'YES', column_key = 'PRI', extra LIKE '%auto_increment%' FROM information_schema.columns " +
+			"WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position",
+		"SELECT DISTINCT index_name FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ?"
+}
+
+func (postgresDialect) catalog() (string, string, string) {
+	return "SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' ORDER BY table_name",
+		"SELECT c.column_name, c.data_type, c.is_nullable = 'YES', EXISTS (SELECT 1 FROM information_schema.table_constraints t " +
+			"JOIN information_schema.key_column_usage k ON k.constraint_name = t.constraint_name AND k.table_schema = t.table_schema " +
+			"WHERE t.constraint_type = 'PRIMARY KEY' AND t.table_schema = c.table_schema AND t.table_name = c.table_name AND k.column_name = c.column_name), " +
+			"COALESCE(c.column_default LIKE 'nextval(%', false) OR c.is_identity = 'YES' FROM information_schema.columns c " +
+			"WHERE c.table_schema = current_schema() AND c.table_name = $1 ORDER BY c.ordinal_position",
+		"SELECT indexname FROM pg_indexes WHERE schemaname = current_schema() AND tablename = $1"
+}
+
+func (sqliteDialect) catalog() (string, string, string) {
+	return "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name",
+		"SELECT name, type, \"notnull\" = 0 AND pk = 0, pk > 0, pk = 1 AND lower(type) = 'integer' AND (SELECT COUNT(*) FROM pragma_table_info(?1) WHERE pk > 0) = 1 " +
+			"FROM pragma_table_info(?1) ORDER BY cid",
+		"SELECT name FROM pragma_index_list(?)"
+}
+
+func (sqlserverDialect) catalog() (string, string, string) {
+	return "SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME",
+		"SELECT c.COLUMN_NAME, c.DATA_TYPE, CASE WHEN c.IS_NULLABLE = 'YES' THEN 1 ELSE 0 END, CASE WHEN k.COLUMN_NAME IS NULL THEN 0 ELSE 1 END, " +
+			"COLUMNPROPERTY(OBJECT_ID(c.TABLE_NAME), c.COLUMN_NAME, 'IsIdentity') FROM INFORMATION_SCHEMA.COLUMNS c " +
+			"LEFT JOIN (INFORMATION_SCHEMA.TABLE_CONSTRAINTS t JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE k ON k.CONSTRAINT_NAME = t.CONSTRAINT_NAME) " +
+			"ON t.TABLE_NAME = c.TABLE_NAME AND t.CONSTRAINT_TYPE = 'PRIMARY KEY' AND k.COLUMN_NAME = c.COLUMN_NAME " +
+			"WHERE c.TABLE_NAME = @p1 ORDER BY c.ORDINAL_POSITION",
+		"SELECT name FROM sys.indexes WHERE object_id = OBJECT_ID(@p1) AND name IS NOT NULL"
+}
+
+func catalogOf(d Dialect) (catalog, error) {
+	c, ok := d.(catalog)
+	if !ok {
+		return nil, fmt.Errorf("gql: %T can't be introspected", d)
+	}
+	return c, nil
+}
+
+// Inspect describes the tables of the schema e is connected to, all of them when none are named.
+func Inspect(ctx context.Context, e Executor, d Dialect, tables ...string) (out []TableInfo, err error) {
+	c, err := catalogOf(d)
+	if err != nil {
+		return nil, err
+	}
+	tablesQuery, _, _ := c.catalog()
+	if len(tables) == 0 {
+		rows, err := e.QueryContext(ctx, tablesQuery)
+		if err != nil {
+			return nil, newQueryError(d, tablesQuery, nil, err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var name string
+			if err = rows.Scan(&name); err != nil {
+				return nil, err
+			}
+			tables = append(tables, name)
+		}
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	for _, table := range tables {
+		info := TableInfo{Name: table}
+		if info.Columns, err = describe(ctx, e, d, c, table); err != nil {
+			return nil, err
+		}
+		out = append(out, info)
+	}
+	return
+}
+
+// describe reads the columns of a table, none when it doesn't exist.
+func describe(ctx context.Context, e Executor, d Dialect, c catalog, table string) (out []ColumnInfo, err error) {
+	_, query, _ := c.catalog()
+	rows, err := e.QueryContext(ctx, query, table)
+	if err != nil {
+		return nil, newQueryError(d, query, []interface{}{table}, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var column ColumnInfo
+		var nullable, pk, auto interface{}
+		if err = rows.Scan(&column.Name, &column.Type, &nullable, &pk, &auto); err != nil {
+			return nil, err
+		}
+		column.Nullable, column.PrimaryKey, column.AutoIncrement = truthy(nullable), truthy(pk), truthy(auto)
+		out = append(out, column)
+	}
+	return out, rows.Err()
+}
+
+func truthy(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case int64:
+		return b != 0
+	case []byte:
+		n, _ := strconv.Atoi(string(b))
+		return n != 0
+	case string:
+		n, _ := strconv.Atoi(b)
+		return n != 0
+	}
+	return false
+}
@@ -3,42 +3,44 @@ package gql
 import (
 	"bytes"
 	"database/sql"
-	"encoding/hex"
+	"database/sql/driver"
 	"fmt"
 	"reflect"
 	"strconv"
-	"strings"
 	"time"
 )
 
 func float_to_string(f float64) string {
 	return strconv.FormatFloat(f, 'f', -1, 64)
 }
-func Convert(value interface{}) (out string) {
+func Convert(value interface{}) string {
+	return convert(defaultDialect, value)
+}
+func convert(dialect Dialect, value interface{}) (out string) {
 	out =  "NULL"
 	switch value.(type) {
 	case string:
 		d := value.(string)
-		out =   `'`+strings.ReplaceAll(d, `'`, `\'`)+`'`
+		out = dialect.String(d)
 		return
 	case []byte:
 		d := value.([]byte)
-		out = "X'"+hex.EncodeToString(d)+"'"
+		out = dialect.Bytes(d)
 	case sql.RawBytes:
 		d := value.(sql.RawBytes)
-		out = "X'"+hex.EncodeToString(d)+"'"
+		out = dialect.Bytes(d)
 	case bytes.Buffer:
 		d := value.(bytes.Buffer)
-		out = "X'"+hex.EncodeToString(d.Bytes())+"'"
+		out = dialect.Bytes(d.Bytes())
 		return
 	case *bytes.Buffer:
 		d := value.(*bytes.Buffer)
-		out = "X'"+hex.EncodeToString(d.Bytes())+"'"
+		out = dialect.Bytes(d.Bytes())
 		return
 	case NullString:
 		d := value.(NullString)
 		if d.Valid {
-			out =  `'`+strings.ReplaceAll(d.String, `'`, `\'`)+`'`
+			out = dialect.String(d.String)
 		}else {
 			out = "NULL"
 		}
@@ -58,24 +60,27 @@ func Convert(value interface{}) (out string) {
 			out = "NULL"
 		}
 	case SqlReserved:
-		out =  (value.(SqlReserved)).content
+		out = (value.(SqlReserved)).render(dialect)
 		return
 	case time.Time:
 		d := value.(time.Time)
-		out =  Convert(d.UTC().Format("2006-01-02 15:04:05"))
+		out = convert(dialect, d.UTC().Format("2006-01-02 15:04:05"))
 		return
 	case NullTime:
 		d := value.(NullTime)
 		if d.Valid {
-			out = Convert(d.Time.UTC().Format("2006-01-02 15:04:05"))
+			out = convert(dialect, d.Time.UTC().Format("2006-01-02 15:04:05"))
 		}else {
 			out = "NULL"
 		}
 		return
 
-	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, bool:
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
 		out =  fmt.Sprintf("%v", value)
 		return
+	case bool:
+		out = dialect.Bool(value.(bool))
+		return
 	case NullInt64:
 		d := value.(NullInt64)
 		if d.Valid {
@@ -93,23 +98,29 @@ func Convert(value interface{}) (out string) {
 	case NullBool:
 		d := value.(NullBool)
 		if d.Valid {
-			if d.Bool {
-				out = "true"
-			} else  {
-				out = "false"
-			}
+			out = dialect.Bool(d.Bool)
 		}else {
 			out = "NULL"
 		}
 	default:
+		if valuer, ok := value.(driver.Valuer); ok {
+			if d, err := valuer.Value(); err == nil {
+				out = convert(dialect, d)
+			}
+			return
+		}
 		v := reflect.ValueOf(value)
 		if v.Kind() != reflect.Slice {
 			return
 		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			out = dialect.Bytes(v.Bytes())
+			return
+		}
 		ln := v.Len()
 		out = "("
 		for i:=0;i<ln ;i++  {
-			val := Convert(v.Index(i).Interface())
+			val := convert(dialect, v.Index(i).Interface())
 			if val != "" {
 				out += val
 				if i != ln - 1 {
@@ -0,0 +1,215 @@
+package gql
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type order struct {
+	name string
+	desc bool
+}
+
+func (o order) render(p *params) string {
+	if o.desc {
+		return p.name(o.name) + " DESC"
+	}
+	return p.name(o.name) + " ASC"
+}
+
+type keyset struct {
+	values []interface{}
+	next   string
+}
+
+// Keyset pages by the OrderBy columns instead of an offset, cursor is empty for the first page or the
+// value handed out by NextCursor. It must be called after OrderBy and the order should end with a
+// unique column such as the primary key. Order columns can't be nullable, the order of NULLs differs
+// between databases and a NULL can't be compared to, so their fields fail the Scan.
+func (b *QueryBuilder) Keyset(take int64, cursor string) Builder {
+	b.limit = take
+	b.offset = 0
+	b.keyset = &keyset{}
+	if cursor == "" {
+		return b
+	}
+	values, err := decodeCursor(cursor)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	if len(values) != len(b.orders) {
+		b.err = fmt.Errorf("gql: cursor has %d values but the query is ordered by %d columns", len(values), len(b.orders))
+		return b
+	}
+	for i, value := range values {
+		if value == nil {
+			b.err = fmt.Errorf("gql: cursor holds NULL for the order column %s", b.orders[i].name)
+			return b
+		}
+	}
+	b.keyset.values = values
+	return b
+}
+
+// NextCursor returns the cursor of the page after the last Scan, or an empty string on the last page.
+func (b *QueryBuilder) NextCursor(cursor *string) Builder {
+	*cursor = ""
+	if b.keyset != nil {
+		*cursor = b.keyset.next
+	}
+	return b
+}
+
+func (k *keyset) clause(p *params, orders []order) string {
+	uniform := true
+	for _, o := range orders {
+		uniform = uniform && o.desc == orders[0].desc
+	}
+	if uniform && p.d.SupportsRowValues() && len(orders) > 1 {
+		names := make([]string, len(orders))
+		values := make([]string, len(orders))
+		for i, o := range orders {
+			names[i] = p.name(o.name)
+			values[i] = p.value(k.values[i])
+		}
+		return "(" + strings.Join(names, ", ") + ") " + seekOp(orders[0]) + " (" + strings.Join(values, ", ") + ")"
+	}
+	ors := make([]string, len(orders))
+	for i, o := range orders {
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, p.name(orders[j].name)+" = "+p.value(k.values[j]))
+		}
+		ands = append(ands, p.name(o.name)+" "+seekOp(o)+" "+p.value(k.values[i]))
+		ors[i] = "(" + strings.Join(ands, " AND ") + ")"
+	}
+	return "(" + strings.Join(ors, " OR ") + ")"
+}
+
+// nullable tells whether a field can hold NULL, as pointers and the Null types do when zero.
+func nullable(t reflect.Type) bool {
+	return isNull(reflect.Zero(t).Interface())
+}
+
+func isNull(value interface{}) bool {
+	v, err := driver.DefaultParameterConverter.ConvertValue(value)
+	return err == nil && v == nil
+}
+
+func seekOp(o order) string {
+	if o.desc {
+		return "<"
+	}
+	return ">"
+}
+
+// capture encodes the order columns of the last scanned row, full tells whether the page was filled.
+func (k *keyset) capture(rows reflect.Value, m *ModelInfo, orders []order, full bool) error {
+	k.next = ""
+	if !full || rows.Len() == 0 {
+		return nil
+	}
+	last := reflect.Indirect(rows.Index(rows.Len() - 1))
+	values := make([]interface{}, len(orders))
+	for i, o := range orders {
+		name := o.name
+		if j := strings.LastIndex(name, "."); j > -1 {
+			name = name[j+1:]
+		}
+		field := m.Column(name)
+		if field == nil {
+			return fmt.Errorf("gql: order column %s is not a field of %s", o.name, m.Type)
+		}
+		value, ok := fieldOf(last, field.Index, false)
+		if !ok {
+			return fmt.Errorf("gql: order column %s is nil", o.name)
+		}
+		if nullable(field.Type) || isNull(value.Interface()) {
+			return fmt.Errorf("gql: order column %s is nullable, keyset pages need non null order columns", o.name)
+		}
+		values[i] = value.Interface()
+	}
+	next, err := encodeCursor(values)
+	if err != nil {
+		return err
+	}
+	k.next = next
+	return nil
+}
+
+func encodeCursor(values []interface{}) (string, error) {
+	pairs := make([][2]string, len(values))
+	for i, value := range values {
+		v, err := driver.DefaultParameterConverter.ConvertValue(value)
+		if err != nil {
+			return "", fmt.Errorf("gql: cannot use %T in a cursor: %v", value, err)
+		}
+		switch d := v.(type) {
+		case nil:
+			pairs[i] = [2]string{"n", ""}
+		case int64:
+			pairs[i] = [2]string{"i", strconv.FormatInt(d, 10)}
+		case float64:
+			pairs[i] = [2]string{"f", float_to_string(d)}
+		case bool:
+			pairs[i] = [2]string{"b", strconv.FormatBool(d)}
+		case string:
+			pairs[i] = [2]string{"s", d}
+		case []byte:
+			pairs[i] = [2]string{"x", hex.EncodeToString(d)}
+		case time.Time:
+			pairs[i] = [2]string{"t", d.Format(time.RFC3339Nano)}
+		default:
+			return "", fmt.Errorf("gql: cannot use %T in a cursor", value)
+		}
+	}
+	data, err := json.Marshal(pairs)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(cursor string) (values []interface{}, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("gql: invalid cursor: %v", err)
+	}
+	var pairs [][2]string
+	if err = json.Unmarshal(data, &pairs); err != nil {
+		return nil, fmt.Errorf("gql: invalid cursor: %v", err)
+	}
+	values = make([]interface{}, len(pairs))
+	for i, pair := range pairs {
+		switch pair[0] {
+		case "n":
+			values[i] = nil
+		case "i":
+			values[i], err = strconv.ParseInt(pair[1], 10, 64)
+		case "f":
+			values[i], err = strconv.ParseFloat(pair[1], 64)
+		case "b":
+			values[i], err = strconv.ParseBool(pair[1])
+		case "s":
+			values[i] = pair[1]
+		case "x":
+			values[i], err = hex.DecodeString(pair[1])
+		case "t":
+			values[i], err = time.Parse(time.RFC3339Nano, pair[1])
+		default:
+			err = fmt.Errorf("unknown value type %q", pair[0])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("gql: invalid cursor: %v", err)
+		}
+	}
+	return
+}
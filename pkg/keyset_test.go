@@ -0,0 +1,71 @@
+package gql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestKeyset(t *testing.T) {
+	cursor, err := encodeCursor([]interface{}{"bob", int64(7)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSQL(t, func() Builder { return Read("users").OrderBy("name", "id").Keyset(10, cursor) }, golden{
+		"mysql":    "SELECT * FROM `users` WHERE (`name`, `id`) > (?, ?) ORDER BY `name` ASC, `id` ASC LIMIT 10",
+		"postgres": `SELECT * FROM "users" WHERE ("name", "id") > ($1, $2) ORDER BY "name" ASC, "id" ASC LIMIT 10`,
+		"sqlite":   `SELECT * FROM "users" WHERE ("name", "id") > (?, ?) ORDER BY "name" ASC, "id" ASC LIMIT 10`,
+	}, "bob", int64(7))
+	checkSQL(t, func() Builder { return Read("users").OrderBy("name", "id").Keyset(10, cursor) }, golden{
+		"sqlserver": "SELECT TOP 10 * FROM [users] WHERE (([name] > @p1) OR ([name] = @p2 AND [id] > @p3)) ORDER BY [name] ASC, [id] ASC",
+	}, "bob", "bob", int64(7))
+	checkSQL(t, func() Builder { return Read("users").OrderBy("-name", "id").Keyset(10, cursor) }, golden{
+		"postgres": `SELECT * FROM "users" WHERE (("name" < $1) OR ("name" = $2 AND "id" > $3)) ORDER BY "name" DESC, "id" ASC LIMIT 10`,
+	}, "bob", "bob", int64(7))
+	checkSQL(t, func() Builder { return Read("users").OrderBy("id").Keyset(10, "") }, golden{
+		"postgres": `SELECT * FROM "users" ORDER BY "id" ASC LIMIT 10`,
+	})
+	type wrapped struct{ Dialect }
+	q, _ := Read("users").OrderBy("name", "id").Keyset(10, cursor).WithDialect(wrapped{SQLServer}).QueryWithArgs()
+	if q != "SELECT TOP 10 * FROM [users] WHERE (([name] > @p1) OR ([name] = @p2 AND [id] > @p3)) ORDER BY [name] ASC, [id] ASC" {
+		t.Error(q)
+	}
+}
+
+func TestCursor(t *testing.T) {
+	values := []interface{}{int64(1), 2.5, true, "s", []byte{1}, time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)}
+	cursor, err := encodeCursor(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := decodeCursor(cursor)
+	if err != nil || !reflect.DeepEqual(decoded, values) {
+		t.Fatalf("%#v, %v", decoded, err)
+	}
+	if _, err = decodeCursor("not a cursor"); err == nil {
+		t.Error("decoded an invalid cursor")
+	}
+	if err = Read("users").OrderBy("name").Keyset(1, cursor).GetError(); err == nil {
+		t.Error("accepted a cursor of more columns than the order")
+	}
+	null, _ := encodeCursor([]interface{}{nil})
+	if err = Read("users").OrderBy("name").Keyset(1, null).GetError(); err == nil {
+		t.Error("accepted a NULL cursor value")
+	}
+}
+
+func TestKeysetNullable(t *testing.T) {
+	type row struct {
+		ID   int64      `gql:"id"`
+		Name NullString `gql:"name"`
+	}
+	m, _ := GetModel(&row{})
+	rows := reflect.ValueOf([]row{{ID: 1, Name: NullString{}}})
+	k := &keyset{}
+	if err := k.capture(rows, m, []order{{name: "name"}, {name: "id"}}, true); err == nil {
+		t.Error("captured a nullable order column")
+	}
+	if err := k.capture(rows, m, []order{{name: "id"}}, true); err != nil || k.next == "" {
+		t.Errorf("%q, %v", k.next, err)
+	}
+}
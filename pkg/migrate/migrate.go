@@ -0,0 +1,291 @@
+// Package migrate applies versioned schema migrations, loaded from files such as
+// "0001_create_users.up.sql" and "0001_create_users.down.sql" or registered as Go functions, and
+// records them in a schema_migrations table.
+//
+// Concurrent runners wait for each other on the lock of the dialect. SQLite has none, there a runner
+// competing with another fails on the busy database or the already recorded version instead. The
+// applied_at column is scanned into a time, MySQL DSNs need parseTime=true.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	gql "github.com/arianito/gql/pkg"
+)
+
+// Func changes the schema inside the transaction of its migration. MySQL commits DDL implicitly, so
+// there a failing migration may be left half applied.
+type Func func(ctx context.Context, tx gql.Executor) error
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      Func
+	Down    Func
+}
+
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+var ErrNoDown = errors.New("migrate: migration has no down step")
+
+type Migrator struct {
+	// Table records the applied versions, schema_migrations by default.
+	Table string
+
+	db         *sql.DB
+	dialect    gql.Dialect
+	migrations map[int64]*Migration
+}
+
+type record struct {
+	Version   int64        `gql:"version"`
+	Name      string       `gql:"name"`
+	AppliedAt gql.NullTime `gql:"applied_at"`
+}
+
+func New(db *sql.DB, d gql.Dialect) *Migrator {
+	return &Migrator{
+		Table:      "schema_migrations",
+		db:         db,
+		dialect:    d,
+		migrations: make(map[int64]*Migration),
+	}
+}
+
+// Add registers a migration written in Go, down may be nil for migrations that can't be undone.
+func (m *Migrator) Add(version int64, name string, up Func, down Func) error {
+	if _, ok := m.migrations[version]; ok {
+		return fmt.Errorf("migrate: version %d is registered twice", version)
+	}
+	m.migrations[version] = &Migration{Version: version, Name: name, Up: up, Down: down}
+	return nil
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Load registers the migration files of dir in fsys, e.g. an embed.FS, other files are ignored. Each
+// file is sent to the database as one statement, multiple statements need a driver that accepts them.
+func (m *Migrator) Load(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	loaded := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		mig, ok := loaded[version]
+		if !ok {
+			if _, ok = m.migrations[version]; ok {
+				return fmt.Errorf("migrate: version %d is registered twice", version)
+			}
+			mig = &Migration{Version: version, Name: match[2]}
+			loaded[version] = mig
+		} else if mig.Name != match[2] {
+			return fmt.Errorf("migrate: version %d is used by %s and %s", version, mig.Name, match[2])
+		}
+		if match[3] == "up" {
+			mig.Up = execFunc(string(data))
+		} else {
+			mig.Down = execFunc(string(data))
+		}
+	}
+	for version, mig := range loaded {
+		m.migrations[version] = mig
+	}
+	return nil
+}
+
+func execFunc(query string) Func {
+	return func(ctx context.Context, tx gql.Executor) error {
+		if strings.TrimSpace(query) == "" {
+			return nil
+		}
+		_, err := tx.ExecContext(ctx, query)
+		return err
+	}
+}
+
+func (m *Migrator) sorted() []*Migration {
+	out := make([]*Migration, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		out = append(out, mig)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Version < out[j].Version
+	})
+	return out
+}
+
+// Up applies every pending migration in version order, each in its own transaction.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.locked(ctx, func(conn *sql.Conn, applied map[int64]record) error {
+		for _, mig := range m.sorted() {
+			if _, ok := applied[mig.Version]; ok || mig.Up == nil {
+				continue
+			}
+			err := gql.TransactionDialect(ctx, conn, m.dialect, nil, func(tx gql.Executor) error {
+				if err := mig.Up(ctx, tx); err != nil {
+					return err
+				}
+				return gql.Create(m.Table).WithDialect(m.dialect).WithContext(ctx).
+					Set("version", mig.Version).
+					Set("name", mig.Name).
+					Set("applied_at", gql.Clock().UTC()).
+					Use(tx).Run().GetError()
+			})
+			if err != nil {
+				return fmt.Errorf("migrate: %d_%s: %w", mig.Version, mig.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Down rolls back the most recently applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.locked(ctx, func(conn *sql.Conn, applied map[int64]record) error {
+		var last *record
+		for version := range applied {
+			if r := applied[version]; last == nil || r.Version > last.Version {
+				last = &r
+			}
+		}
+		if last == nil {
+			return nil
+		}
+		mig, ok := m.migrations[last.Version]
+		if !ok {
+			return fmt.Errorf("migrate: applied version %d_%s is not registered", last.Version, last.Name)
+		}
+		if mig.Down == nil {
+			return fmt.Errorf("%w: %d_%s", ErrNoDown, mig.Version, mig.Name)
+		}
+		err := gql.TransactionDialect(ctx, conn, m.dialect, nil, func(tx gql.Executor) error {
+			if err := mig.Down(ctx, tx); err != nil {
+				return err
+			}
+			return gql.Delete(m.Table).WithDialect(m.dialect).WithContext(ctx).
+				Where("version", mig.Version).
+				Use(tx).Run().GetError()
+		})
+		if err != nil {
+			return fmt.Errorf("migrate: %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		return nil
+	})
+}
+
+// Status lists the registered migrations and the applied ones that are no longer registered.
+func (m *Migrator) Status(ctx context.Context) (out []Status, err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return
+	}
+	for _, mig := range m.sorted() {
+		r, ok := applied[mig.Version]
+		out = append(out, Status{Version: mig.Version, Name: mig.Name, Applied: ok, AppliedAt: r.AppliedAt.Time})
+		delete(applied, mig.Version)
+	}
+	for _, r := range applied {
+		out = append(out, Status{Version: r.Version, Name: r.Name, Applied: true, AppliedAt: r.AppliedAt.Time})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Version < out[j].Version
+	})
+	return
+}
+
+// locked runs fn on a single connection holding the migration lock, so concurrent runners wait for
+// each other instead of applying the same version twice.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, applied map[int64]record) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	unlock, err := m.lock(ctx, conn)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(conn, applied)
+}
+
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn) (unlock func(), err error) {
+	if m.dialect == nil {
+		return nil, errors.New("migrate: New needs the dialect of the database to lock it")
+	}
+	name := "gql_migrate_" + m.Table
+	acquire, release := m.dialect.Lock(name)
+	if acquire == "" {
+		return func() {}, nil
+	}
+	var ok sql.NullInt64
+	if err = conn.QueryRowContext(ctx, acquire).Scan(&ok); err != nil {
+		return nil, err
+	}
+	if ok.Int64 != 1 {
+		return nil, fmt.Errorf("migrate: could not take the lock %s", name)
+	}
+	return func() {
+		conn.ExecContext(context.Background(), release)
+	}, nil
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]record, error) {
+	err := gql.CreateTable(m.Table).IfNotExists().
+		Column("version", gql.BigInt().PrimaryKey()).
+		Column("name", gql.Varchar(255).NotNull()).
+		Column("applied_at", gql.Timestamp().NotNull()).
+		WithDialect(m.dialect).WithContext(ctx).Use(conn).Run().GetError()
+	if err != nil {
+		return nil, err
+	}
+	var records []record
+	err = gql.Read(m.Table).WithDialect(m.dialect).WithContext(ctx).
+		Columns("version", "name", "applied_at").
+		Use(conn).Scan(&records).GetError()
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]record, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
@@ -0,0 +1,267 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	gql "github.com/arianito/gql/pkg"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/0002_add_age.up.sql":        {Data: []byte("ALTER TABLE users ADD age INT")},
+		"sql/0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id INT)")},
+		"sql/0001_create_users.down.sql": {Data: []byte("DROP TABLE users")},
+		"sql/README.md":                  {Data: []byte("ignored")},
+	}
+	m := New(nil, nil)
+	if err := m.Load(fsys, "sql"); err != nil {
+		t.Fatal(err)
+	}
+	sorted := m.sorted()
+	if len(sorted) != 2 || sorted[0].Version != 1 || sorted[0].Name != "create_users" || sorted[1].Version != 2 {
+		t.Fatalf("%+v", sorted)
+	}
+	if sorted[0].Down == nil || sorted[1].Down != nil || sorted[1].Up == nil {
+		t.Error("up and down steps not loaded")
+	}
+	if err := m.Load(fsys, "sql"); err == nil {
+		t.Error("loaded the same versions twice")
+	}
+	if err := m.Add(2, "again", nil, nil); err == nil {
+		t.Error("added a version twice")
+	}
+}
+
+func TestLoadNameMismatch(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_a.up.sql":   {Data: []byte("SELECT 1")},
+		"0001_b.down.sql": {Data: []byte("SELECT 1")},
+	}
+	if err := New(nil, nil).Load(fsys, "."); err == nil {
+		t.Error("loaded one version under two names")
+	}
+}
+
+// fakeDB is a database/sql driver answering queries from a script, it records what it's sent.
+type fakeDB struct {
+	mu      sync.Mutex
+	log     []string
+	answers map[string][][]driver.Value
+	fail    string
+}
+
+func newFakeDB(t *testing.T, answers map[string][][]driver.Value) (*sql.DB, *fakeDB) {
+	f := &fakeDB{answers: answers}
+	db := sql.OpenDB(f)
+	t.Cleanup(func() { db.Close() })
+	return db, f
+}
+
+func (f *fakeDB) run(query string) (*fakeRows, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.log = append(f.log, query)
+	if f.fail != "" && strings.HasPrefix(query, f.fail) {
+		return nil, errors.New("fake: " + f.fail + " failed")
+	}
+	for prefix, rows := range f.answers {
+		if strings.HasPrefix(query, prefix) {
+			return &fakeRows{rows: rows}, nil
+		}
+	}
+	return &fakeRows{}, nil
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+
+func (f *fakeDB) Driver() driver.Driver { return nil }
+
+type fakeConn struct{ f *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("fake: no prepare") }
+
+func (c fakeConn) Close() error { return nil }
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	_, err := c.f.run("BEGIN")
+	return c, err
+}
+
+func (c fakeConn) Commit() error {
+	_, err := c.f.run("COMMIT")
+	return err
+}
+
+func (c fakeConn) Rollback() error {
+	_, err := c.f.run("ROLLBACK")
+	return err
+}
+
+func (c fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	return c.f.run(query)
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if _, err := c.f.run(query); err != nil {
+		return nil, err
+	}
+	return fakeResult{}, nil
+}
+
+type fakeResult struct{}
+
+func (fakeResult) LastInsertId() (int64, error) { return 0, nil }
+
+func (fakeResult) RowsAffected() (int64, error) { return 1, nil }
+
+// fakeRows are rows of the version, name and applied_at columns, or of a single column.
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) > 0 && len(r.rows[0]) == 1 {
+		return []string{"ok"}
+	}
+	return []string{"version", "name", "applied_at"}
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func testMigrator(t *testing.T, d gql.Dialect, answers map[string][][]driver.Value) (*Migrator, *fakeDB) {
+	db, f := newFakeDB(t, answers)
+	m := New(db, d)
+	m.Add(1, "create_users", execFunc("CREATE users"), execFunc("DROP users"))
+	m.Add(2, "add_age", execFunc("ALTER users"), nil)
+	return m, f
+}
+
+func checkLog(t *testing.T, f *fakeDB, want ...string) {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var got []string
+	for _, query := range f.log {
+		if !strings.HasPrefix(query, "CREATE TABLE") {
+			got = append(got, query)
+		}
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("statements:\n got %q\nwant %q", got, want)
+	}
+}
+
+const (
+	mysqlLock   = "SELECT GET_LOCK('gql_migrate_schema_migrations', -1)"
+	mysqlUnlock = "SELECT RELEASE_LOCK('gql_migrate_schema_migrations')"
+	readApplied = "SELECT `version`, `name`, `applied_at` FROM `schema_migrations`"
+)
+
+func TestUp(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	gql.SetClock(func() time.Time { return now })
+	defer gql.SetClock(nil)
+	m, f := testMigrator(t, gql.MySQL, map[string][][]driver.Value{
+		"SELECT GET_LOCK": {{1}},
+		readApplied:       {{1, "create_users", now}},
+	})
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	checkLog(t, f, mysqlLock, readApplied,
+		"BEGIN", "ALTER users", "INSERT INTO `schema_migrations`(`version`, `name`, `applied_at`) VALUES(?, ?, ?)", "COMMIT",
+		mysqlUnlock)
+
+	m, f = testMigrator(t, gql.MySQL, map[string][][]driver.Value{"SELECT GET_LOCK": {{1}}})
+	f.fail = "ALTER"
+	if err := m.Up(context.Background()); err == nil || !strings.Contains(err.Error(), "2_add_age") {
+		t.Errorf("failed migration: %v", err)
+	}
+	checkLog(t, f, mysqlLock, readApplied,
+		"BEGIN", "CREATE users", "INSERT INTO `schema_migrations`(`version`, `name`, `applied_at`) VALUES(?, ?, ?)", "COMMIT",
+		"BEGIN", "ALTER users", "ROLLBACK",
+		mysqlUnlock)
+}
+
+func TestDown(t *testing.T) {
+	applied := [][]driver.Value{{1, "create_users", time.Now()}, {2, "add_age", time.Now()}}
+	m, f := testMigrator(t, gql.MySQL, map[string][][]driver.Value{"SELECT GET_LOCK": {{1}}, readApplied: applied})
+	if err := m.Down(context.Background()); !errors.Is(err, ErrNoDown) {
+		t.Errorf("down without a down step: %v", err)
+	}
+	checkLog(t, f, mysqlLock, readApplied, mysqlUnlock)
+
+	m, f = testMigrator(t, gql.MySQL, map[string][][]driver.Value{"SELECT GET_LOCK": {{1}}, readApplied: applied[:1]})
+	if err := m.Down(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	checkLog(t, f, mysqlLock, readApplied,
+		"BEGIN", "DROP users", "DELETE FROM `schema_migrations` WHERE `version` = ?", "COMMIT",
+		mysqlUnlock)
+}
+
+func TestStatus(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	m, _ := testMigrator(t, gql.MySQL, map[string][][]driver.Value{
+		readApplied: {{1, "create_users", at}, {7, "gone", at}},
+	})
+	status, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Status{
+		{Version: 1, Name: "create_users", Applied: true, AppliedAt: at},
+		{Version: 2, Name: "add_age"},
+		{Version: 7, Name: "gone", Applied: true, AppliedAt: at},
+	}
+	if !reflect.DeepEqual(status, want) {
+		t.Errorf("%+v", status)
+	}
+}
+
+func TestLock(t *testing.T) {
+	m, f := testMigrator(t, gql.MySQL, map[string][][]driver.Value{"SELECT GET_LOCK": {{0}}})
+	if err := m.Up(context.Background()); err == nil {
+		t.Error("ran without the lock")
+	}
+	checkLog(t, f, mysqlLock)
+
+	m, f = testMigrator(t, gql.PostgreSQL, map[string][][]driver.Value{"SELECT 1 FROM pg_advisory_lock": {{1}}})
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if log := f.log; !strings.HasPrefix(log[0], "SELECT 1 FROM pg_advisory_lock(") || !strings.HasPrefix(log[len(log)-1], "SELECT pg_advisory_unlock(") {
+		t.Errorf("postgres lock: %q", log)
+	}
+
+	m, f = testMigrator(t, gql.SQLite, nil)
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(strings.Join(f.log, "\n"), "LOCK") {
+		t.Errorf("sqlite lock: %q", f.log)
+	}
+
+	if _, err := New(nil, nil).lock(context.Background(), nil); err == nil {
+		t.Error("locked without a dialect")
+	}
+}
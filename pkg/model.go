@@ -0,0 +1,318 @@
+package gql
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+type FieldInfo struct {
+	Name           string
+	Column         string
+	Index          []int
+	Type           reflect.Type
+	Tagged         bool
+	PrimaryKey     bool
+	AutoIncrement  bool
+	OmitEmpty      bool
+	ReadOnly       bool
+	SoftDelete     bool
+	AutoCreateTime bool
+	AutoUpdateTime bool
+	Version        bool
+	Options        map[string]string
+}
+
+type ModelInfo struct {
+	Type          reflect.Type
+	Table         string
+	Fields        []*FieldInfo
+	PrimaryKeys   []*FieldInfo
+	AutoIncrement *FieldInfo
+	SoftDelete    *FieldInfo
+	Version       *FieldInfo
+	Relations     []*Relation
+	columns       map[string]*FieldInfo
+	names         map[string]*FieldInfo
+}
+
+type tabler interface {
+	TableName() string
+}
+
+var models sync.Map
+
+// GetModel returns the cached mapping for the struct behind v, which may be a struct, a pointer to
+// one, a slice of either or a reflect.Type.
+func GetModel(v interface{}) (*ModelInfo, error) {
+	t, ok := v.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(v)
+	}
+	return getModel(t)
+}
+
+func getModel(t reflect.Type) (*ModelInfo, error) {
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %v is not a struct", ErrInvalidTarget, t)
+	}
+	if m, ok := models.Load(t); ok {
+		return m.(*ModelInfo), nil
+	}
+	m := parseModel(t)
+	actual, _ := models.LoadOrStore(t, m)
+	return actual.(*ModelInfo), nil
+}
+
+func parseModel(t reflect.Type) *ModelInfo {
+	m := &ModelInfo{
+		Type:    t,
+		columns: make(map[string]*FieldInfo),
+		names:   make(map[string]*FieldInfo),
+	}
+	if tb, ok := reflect.New(t).Interface().(tabler); ok {
+		m.Table = tb.TableName()
+	} else {
+		m.Table = Plural(snakeCase(t.Name()))
+	}
+	m.parseFields(t, nil)
+
+	for _, f := range m.Fields {
+		if f.PrimaryKey {
+			m.PrimaryKeys = append(m.PrimaryKeys, f)
+		}
+	}
+	// a field tagged "id" stays the implicit auto increment primary key unless another one is declared
+	if len(m.PrimaryKeys) == 0 {
+		if f, ok := m.columns["id"]; ok && f.Tagged {
+			f.PrimaryKey = true
+			f.AutoIncrement = true
+			m.PrimaryKeys = []*FieldInfo{f}
+		}
+	}
+	for _, f := range m.Fields {
+		if f.AutoIncrement && m.AutoIncrement == nil {
+			m.AutoIncrement = f
+		}
+		if f.SoftDelete && m.SoftDelete == nil {
+			m.SoftDelete = f
+		}
+		if f.Version && m.Version == nil {
+			m.Version = f
+		}
+	}
+	return m
+}
+
+func (m *ModelInfo) parseFields(t reflect.Type, index []int) {
+	ln := t.NumField()
+	for i := 0; i < ln; i++ {
+		field := t.Field(i)
+		tag, hasTag := field.Tag.Lookup("gql")
+		idx := append(append([]int{}, index...), i)
+
+		ft := field.Type
+		if field.Anonymous && tag == "" {
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				m.parseFields(ft, idx)
+				continue
+			}
+		}
+		parts := strings.Split(tag, ",")
+		if field.PkgPath != "" {
+			continue
+		}
+		options := make(map[string]string)
+		for _, option := range parts[1:] {
+			key, value := option, ""
+			if j := strings.Index(option, "="); j > -1 {
+				key, value = option[:j], option[j+1:]
+			}
+			options[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+		if rel := newRelation(field, idx, options); rel != nil {
+			m.Relations = append(m.Relations, rel)
+			continue
+		}
+		if parts[0] == "-" {
+			continue
+		}
+
+		f := &FieldInfo{
+			Name:    field.Name,
+			Column:  field.Name,
+			Index:   idx,
+			Type:    field.Type,
+			Options: make(map[string]string),
+		}
+		if hasTag && tag != "" {
+			if parts[0] != "" {
+				f.Column = parts[0]
+			}
+			f.Tagged = true
+			f.Options = options
+			_, f.PrimaryKey = f.Options["pk"]
+			_, f.AutoIncrement = f.Options["autoincrement"]
+			_, f.OmitEmpty = f.Options["omitempty"]
+			_, f.ReadOnly = f.Options["readonly"]
+			_, f.SoftDelete = f.Options["softdelete"]
+			_, f.AutoCreateTime = f.Options["autocreatetime"]
+			_, f.AutoUpdateTime = f.Options["autoupdatetime"]
+			_, f.Version = f.Options["version"]
+		}
+		m.add(f)
+	}
+}
+
+// add registers f, a field promoted from an embedded struct loses against a shallower one like in Go.
+func (m *ModelInfo) add(f *FieldInfo) {
+	if existing, ok := m.columns[f.Column]; ok {
+		if len(existing.Index) <= len(f.Index) {
+			return
+		}
+		for i, field := range m.Fields {
+			if field == existing {
+				m.Fields[i] = f
+			}
+		}
+		delete(m.names, existing.Name)
+	} else {
+		m.Fields = append(m.Fields, f)
+	}
+	m.columns[f.Column] = f
+	m.names[f.Name] = f
+}
+
+func (m *ModelInfo) Column(column string) *FieldInfo {
+	return m.columns[column]
+}
+
+func (m *ModelInfo) Field(name string) *FieldInfo {
+	return m.names[name]
+}
+
+func (m *ModelInfo) Relation(name string) *Relation {
+	for _, rel := range m.Relations {
+		if rel.Name == name {
+			return rel
+		}
+	}
+	return nil
+}
+
+// key is the column other tables reference the model by.
+func (m *ModelInfo) key() string {
+	if len(m.PrimaryKeys) > 0 {
+		return m.PrimaryKeys[0].Column
+	}
+	return "id"
+}
+
+// writable reports whether the field is sent to the database by Bind for the given statement type.
+func (f *FieldInfo) writable(typ SqlTyp) bool {
+	if !f.Tagged || f.ReadOnly || f.AutoIncrement {
+		return false
+	}
+	return typ != SqlTypUpdate || !f.PrimaryKey && !f.AutoCreateTime && !f.Version
+}
+
+func snakeCase(name string) string {
+	out := make([]rune, 0, len(name)+4)
+	runes := []rune(name)
+	for i, c := range runes {
+		if unicode.IsUpper(c) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				out = append(out, '_')
+			}
+			c = unicode.ToLower(c)
+		}
+		out = append(out, c)
+	}
+	return string(out)
+}
+
+type scanPlan struct {
+	fields [][]int
+}
+
+type planKey struct {
+	t       reflect.Type
+	columns string
+}
+
+var plans sync.Map
+
+// plan resolves the result columns to field index paths once per type and column set.
+func (m *ModelInfo) plan(columns []string) *scanPlan {
+	key := planKey{t: m.Type, columns: strings.Join(columns, "\x00")}
+	if p, ok := plans.Load(key); ok {
+		return p.(*scanPlan)
+	}
+	p := &scanPlan{fields: make([][]int, len(columns))}
+	for i, column := range columns {
+		if field := m.columns[column]; field != nil {
+			p.fields[i] = field.Index
+		}
+	}
+	actual, _ := plans.LoadOrStore(key, p)
+	return actual.(*scanPlan)
+}
+
+// targets fills ifc with the rows.Scan destinations inside v, columns without a field are discarded.
+func (p *scanPlan) targets(v reflect.Value, ifc []interface{}) []interface{} {
+	if ifc == nil {
+		ifc = make([]interface{}, len(p.fields))
+	}
+	for i, index := range p.fields {
+		if index != nil {
+			if value, ok := fieldOf(v, index, true); ok {
+				ifc[i] = value.Addr().Interface()
+				continue
+			}
+		}
+		ifc[i] = new(interface{})
+	}
+	return ifc
+}
+
+// fieldOf walks index from v, allocating nil embedded pointers on the way when alloc is set.
+func fieldOf(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	if len(index) == 1 {
+		return v.Field(index[0]), true
+	}
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc || !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func setInt(v reflect.Value, n int64) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(n))
+	default:
+		if scanner, ok := v.Addr().Interface().(sql.Scanner); ok {
+			scanner.Scan(n)
+		}
+	}
+}
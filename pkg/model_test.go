@@ -0,0 +1,49 @@
+package gql
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+type PlanAudit struct {
+	Note string `gql:"note"`
+}
+
+type planUser struct {
+	ID   int64  `gql:"id"`
+	Name string `gql:"name"`
+	*PlanAudit
+}
+
+func TestScanPlan(t *testing.T) {
+	m, err := GetModel(&planUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := m.plan([]string{"id", "name"})
+	if m.plan([]string{"id", "name"}) != p {
+		t.Error("the plan of a column set isn't cached")
+	}
+	if m.plan([]string{"name", "id"}) == p {
+		t.Error("column sets in another order share a plan")
+	}
+
+	db, _ := newFakeDB(t,
+		fakeAnswer{columns: []string{"name", "extra", "note", "id"}, rows: [][]driver.Value{{"a", 1, "x", 1}, {"b", 2, "y", 2}}},
+		fakeAnswer{columns: []string{"id", "name"}, rows: [][]driver.Value{{3, "c"}}},
+	)
+	var users []planUser
+	if err = Read("").Model(&planUser{}).Use(db).Scan(&users).GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[1].ID != 2 || users[1].Name != "b" || users[1].PlanAudit == nil || users[1].Note != "y" {
+		t.Errorf("%+v", users)
+	}
+	var user planUser
+	if err = Read("").Model(&planUser{}).Use(db).First(&user).GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != 3 || user.Name != "c" || user.PlanAudit != nil {
+		t.Errorf("%+v", user)
+	}
+}
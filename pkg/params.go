@@ -0,0 +1,90 @@
+package gql
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+)
+
+type fragment func(p *params) string
+
+type params struct {
+	d    Dialect
+	bind bool
+	args []interface{}
+	err  error
+}
+
+func (p *params) name(name string) string {
+	return quoteName(p.d, name)
+}
+
+func (p *params) value(value interface{}) string {
+	switch v := value.(type) {
+	case SqlSubquery:
+		return v.render(p)
+	case *SqlSubquery:
+		return v.render(p)
+	}
+	if !p.bind {
+		return convert(p.d, value)
+	}
+	switch v := value.(type) {
+	case SqlReserved:
+		return v.render(p.d)
+	case *SqlReserved:
+		return v.render(p.d)
+	case []byte, sql.RawBytes:
+		return p.arg(value)
+	case bytes.Buffer:
+		return p.arg(v.Bytes())
+	case *bytes.Buffer:
+		return p.arg(v.Bytes())
+	case driver.Valuer:
+		return p.arg(value)
+	}
+	// plain slices expand for IN, byte slices like json.RawMessage are a single value
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		ln := v.Len()
+		out := make([]string, ln)
+		for i := 0; i < ln; i++ {
+			out[i] = p.value(v.Index(i).Interface())
+		}
+		return "(" + strings.Join(out, ",") + ")"
+	}
+	return p.arg(value)
+}
+
+func (p *params) arg(value interface{}) string {
+	p.args = append(p.args, value)
+	return p.d.Placeholder(len(p.args))
+}
+
+// custom renders a query whose arguments are marked by ?, each is bound or inlined like any other value.
+// A query without ? marks is taken to use the placeholders of the dialect and gets its arguments as is.
+func (p *params) custom(query string, args []interface{}) string {
+	if len(args) == 0 {
+		return query
+	}
+	out := ""
+	quoted := false
+	i := 0
+	for _, c := range query {
+		if c == '\'' {
+			quoted = !quoted
+		}
+		if c == '?' && !quoted && i < len(args) {
+			out += p.value(args[i])
+			i++
+			continue
+		}
+		out += string(c)
+	}
+	if i == 0 && p.bind {
+		p.args = append(p.args, args...)
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package gql
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCustom(t *testing.T) {
+	checkSQL(t, func() Builder {
+		return Custom("SELECT * FROM users WHERE name = ? AND note = '?' AND age > ?", "a", 3)
+	}, golden{
+		"mysql":     "SELECT * FROM users WHERE name = ? AND note = '?' AND age > ?",
+		"postgres":  "SELECT * FROM users WHERE name = $1 AND note = '?' AND age > $2",
+		"sqlite":    "SELECT * FROM users WHERE name = ? AND note = '?' AND age > ?",
+		"sqlserver": "SELECT * FROM users WHERE name = @p1 AND note = '?' AND age > @p2",
+	}, "a", 3)
+	checkSQL(t, func() Builder { return Custom("SELECT * FROM users WHERE id IN ?", []int{1, 2}) }, golden{
+		"postgres": "SELECT * FROM users WHERE id IN ($1,$2)",
+	}, 1, 2)
+	checkSQL(t, func() Builder { return Custom("SELECT * FROM users WHERE name = $1", "a") }, golden{
+		"postgres": "SELECT * FROM users WHERE name = $1",
+	}, "a")
+}
+
+type tagList []string
+
+func (l tagList) Value() (driver.Value, error) { return "{" + strings.Join(l, ",") + "}", nil }
+
+func TestSliceValues(t *testing.T) {
+	checkSQL(t, func() Builder {
+		return Update("users").Set("meta", json.RawMessage(`{}`)).Set("tags", tagList{"a", "b"}).WhereIn("id", []interface{}{1, 2})
+	}, golden{
+		"postgres": `UPDATE "users" SET "meta"=$1, "tags"=$2 WHERE "id" in ($3,$4)`,
+	}, json.RawMessage(`{}`), tagList{"a", "b"}, 1, 2)
+	want := `SELECT * FROM "users" WHERE "meta" = X'7b7d' AND "tags" = '{a,b}'`
+	if q := Read("users").WithDialect(SQLite).Where("meta", json.RawMessage(`{}`)).Where("tags", tagList{"a", "b"}).Query(); q != want {
+		t.Errorf("\n got %s\nwant %s", q, want)
+	}
+}
@@ -1,47 +1,68 @@
 package gql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"reflect"
+	"sort"
 	"strings"
 )
 
 type QueryBuilder struct {
 	values  []*OBJ
+	keys    []string
 	tables  []string
-	columns []string
-	wheres  []string
-	orders  []string
+	columns []fragment
+	wheres  []fragment
+	orders  []order
 	groups  []string
-	joins   []string
-	having  string
+	joins   []fragment
+	having  *QueryBuilder
 	ops     []SqlOp
 	stp     SqlOp
 	typ     SqlTyp
+	dialect Dialect
 
 	limit  int64
 	offset int64
-	tx     *sql.Tx
-	db     *sql.DB
-	u      interface{}
+	ctx    context.Context
+	exec   Executor
 	//
 	obj            interface{}
-	fldTag         map[string]string
+	bindMode       int
+	bindKeys       []string
+	sets           []assignment
+	model          *ModelInfo
+	keyset         *keyset
+	upsert         *upsert
+	returning      []string
+	unsafe         bool
+	trashed        int
+	force          bool
+	preloads       []string
+	subqueries     map[string]SqlSubquery
 	lastInsertedId int64
 	rowsAffected   int64
 	fln            int64
-	cursor         *sql.Rows
 	err            error
-	structFields   map[string]int
 	customQuery    string
+	customArgs     []interface{}
 	//
 }
 
+// assignment is a column Set after Bind, kept to be written over the bound values when they're read again.
+type assignment struct {
+	column string
+	value  interface{}
+}
+
 func (b *QueryBuilder) extractName(name string) string {
-	if val, ok := b.fldTag[name]; ok {
-		return val
+	if b.model != nil {
+		if field := b.model.Field(name); field != nil {
+			return field.Column
+		}
 	}
 	return name
 }
@@ -51,6 +72,7 @@ func (b *QueryBuilder) Field(name string) SqlReserved {
 }
 func (b *QueryBuilder) Fill(values ...*OBJ) Builder {
 	b.values = values
+	b.keys = nil
 	return b
 }
 
@@ -58,13 +80,30 @@ func (b *QueryBuilder) Columns(columns ...interface{}) Builder {
 	for _, column := range columns {
 		switch column.(type) {
 		case string:
-			b.columns = append(b.columns, b.extractName(column.(string)))
+			name := b.extractName(column.(string))
+			b.columns = append(b.columns, func(p *params) string {
+				return p.name(name)
+			})
 			break
 		case SqlReserved:
-			b.columns = append(b.columns, (column.(SqlReserved)).content)
+			reserved := column.(SqlReserved)
+			b.columns = append(b.columns, func(p *params) string {
+				return reserved.render(p.d)
+			})
 			break
 		case *SqlReserved:
-			b.columns = append(b.columns, (column.(*SqlReserved)).content)
+			reserved := *column.(*SqlReserved)
+			b.columns = append(b.columns, func(p *params) string {
+				return reserved.render(p.d)
+			})
+			break
+		case SqlSubquery:
+			sub := column.(SqlSubquery)
+			b.columns = append(b.columns, sub.render)
+			break
+		case *SqlSubquery:
+			sub := *column.(*SqlSubquery)
+			b.columns = append(b.columns, sub.render)
 			break
 		}
 	}
@@ -75,69 +114,67 @@ func (b *QueryBuilder) Table(table string) Builder {
 	return b
 }
 
-func (b *QueryBuilder) Join(table string, condition string, fn ...func(b Builder)) Builder {
+func (b *QueryBuilder) join(kind string, table string, condition string, fn []func(b Builder)) Builder {
 	bld := &QueryBuilder{
 		typ: SqlTypRead,
 	}
 	if len(fn) > 0 {
 		fn[0](bld)
 	}
-	join := "JOIN " + table + " ON " + condition + " " + bld.getWhereClauses(true)
-	b.joins = append(b.joins, join)
+	b.joins = append(b.joins, func(p *params) string {
+		out := kind + " " + p.name(table) + " ON " + condition
+		if len(bld.wheres) > 0 {
+			out += " " + bld.getWhereClauses(p, true)
+		}
+		return out
+	})
 	return b
 }
 
+func (b *QueryBuilder) Join(table string, condition string, fn ...func(b Builder)) Builder {
+	return b.join("JOIN", table, condition, fn)
+}
+
 func (b *QueryBuilder) LeftJoin(table string, condition string, fn ...func(b Builder)) Builder {
-	bld := &QueryBuilder{
-		typ: SqlTypRead,
-	}
-	if len(fn) > 0 {
-		fn[0](bld)
-	}
-	join := "LEFT JOIN " + table + " ON " + condition + " " + bld.getWhereClauses(true)
-	b.joins = append(b.joins, join)
-	return b
+	return b.join("LEFT JOIN", table, condition, fn)
 }
 
 func (b *QueryBuilder) RightJoin(table string, condition string, fn ...func(b Builder)) Builder {
-	bld := &QueryBuilder{
-		typ: SqlTypRead,
-	}
-	if len(fn) > 0 {
-		fn[0](bld)
-	}
-	join := "RIGHT JOIN " + table + " ON " + condition + " " + bld.getWhereClauses(true)
-	b.joins = append(b.joins, join)
-	return b
+	return b.join("RIGHT JOIN", table, condition, fn)
 }
 
 func (b *QueryBuilder) JoinUsing(table string, using string) Builder {
-	join := "JOIN " + table + " USING(" + b.extractName(using) + ")"
-	b.joins = append(b.joins, join)
+	name := b.extractName(using)
+	b.joins = append(b.joins, func(p *params) string {
+		return "JOIN " + p.name(table) + " USING(" + p.name(name) + ")"
+	})
 	return b
 }
 
 func (b *QueryBuilder) BitwiseAnd(field string, with int64, value int64) Builder {
-	b.ops = append(b.ops, b.stp)
-	b.wheres = append(b.wheres, fmt.Sprintf("%s & %v = %v", b.extractName(field), with, value))
-	return b
+	name := b.extractName(field)
+	return b.where(func(p *params) string {
+		return p.name(name) + " & " + p.value(with) + " = " + p.value(value)
+	})
 }
 
 func (b *QueryBuilder) BitwiseOr(field string, with int64, value int64) Builder {
-	b.ops = append(b.ops, b.stp)
-	b.wheres = append(b.wheres, fmt.Sprintf("%s | %v = %v", b.extractName(field), with, value))
-	return b
+	name := b.extractName(field)
+	return b.where(func(p *params) string {
+		return p.name(name) + " | " + p.value(with) + " = " + p.value(value)
+	})
 }
 
 func (b *QueryBuilder) OrderBy(clause ...string) Builder {
 	for _, name := range clause {
+		desc := false
 		if name[0] == '-' {
-			b.orders = append(b.orders, b.extractName(name[1:])+" DESC")
+			name = name[1:]
+			desc = true
 		} else if name[0] == '+' {
-			b.orders = append(b.orders, b.extractName(name[1:])+" ASC")
-		} else {
-			b.orders = append(b.orders, b.extractName(name)+" ASC")
+			name = name[1:]
 		}
+		b.orders = append(b.orders, order{name: b.extractName(name), desc: desc})
 	}
 	return b
 }
@@ -153,90 +190,105 @@ func (b *QueryBuilder) Having(fn func(b Builder)) Builder {
 		typ: SqlTypRead,
 	}
 	fn(bld)
-	b.having = bld.getWhereClauses(false)
+	b.having = bld
 	return b
 }
 
-func (b *QueryBuilder) Where(field string, value interface{}) Builder {
+func (b *QueryBuilder) where(cls fragment) Builder {
 	b.ops = append(b.ops, b.stp)
-	b.wheres = append(b.wheres, fmt.Sprintf("%s = %s", b.extractName(field), Convert(value)))
+	b.wheres = append(b.wheres, cls)
 	return b
 }
+
+func (b *QueryBuilder) compare(field string, op string, value interface{}) Builder {
+	name := b.extractName(field)
+	return b.where(func(p *params) string {
+		return p.name(name) + " " + op + " " + p.value(value)
+	})
+}
+
+func (b *QueryBuilder) Where(field string, value interface{}) Builder {
+	return b.compare(field, "=", value)
+}
 func (b *QueryBuilder) WhereLike(field string, value interface{}) Builder {
-	b.ops = append(b.ops, b.stp)
-	b.wheres = append(b.wheres, fmt.Sprintf("%s LIKE %s", b.extractName(field), Convert(value)))
-	return b
+	return b.compare(field, "LIKE", value)
 }
 func (b *QueryBuilder) WhereNotLike(field string, value interface{}) Builder {
-	b.ops = append(b.ops, b.stp)
-	b.wheres = append(b.wheres, fmt.Sprintf("%s NOT LIKE %s", b.extractName(field), Convert(value)))
-	return b
+	return b.compare(field, "NOT LIKE", value)
 }
+
+// Find filters by the primary key of the model, a composite key takes its values as a slice in field order.
 func (b *QueryBuilder) Find(value interface{}) Builder {
-	b.ops = append(b.ops, b.stp)
-	b.wheres = append(b.wheres, fmt.Sprintf("%s = %s", b.extractName("id"), Convert(value)))
-	return b
+	if b.model == nil || len(b.model.PrimaryKeys) < 2 {
+		return b.compare(b.primaryKey(), "=", value)
+	}
+	values := reflect.ValueOf(value)
+	if values.Kind() != reflect.Slice || values.Len() != len(b.model.PrimaryKeys) {
+		b.err = fmt.Errorf("gql: %s has a composite primary key, Find needs %d values", b.model.Type, len(b.model.PrimaryKeys))
+		return b
+	}
+	return b.WhereGroup(func(g Builder) {
+		for i, field := range b.model.PrimaryKeys {
+			g.Where(field.Column, values.Index(i).Interface())
+		}
+	})
+}
+
+func (b *QueryBuilder) primaryKey() string {
+	if b.model != nil && len(b.model.PrimaryKeys) > 0 {
+		return b.model.PrimaryKeys[0].Column
+	}
+	return "id"
 }
 func (b *QueryBuilder) WhereNot(field string, value interface{}) Builder {
-	b.ops = append(b.ops, b.stp)
-	b.wheres = append(b.wheres, fmt.Sprintf("%s != %s", b.extractName(field), Convert(value)))
-	return b
+	return b.compare(field, "!=", value)
 }
 
 func (b *QueryBuilder) WhereNull(field string) Builder {
-	b.ops = append(b.ops, b.stp)
-	b.wheres = append(b.wheres, fmt.Sprintf("%s IS NULL", b.extractName(field)))
-	return b
+	name := b.extractName(field)
+	return b.where(func(p *params) string {
+		return p.name(name) + " IS NULL"
+	})
 }
 
 func (b *QueryBuilder) WhereNotNull(field string) Builder {
-	b.ops = append(b.ops, b.stp)
-	b.wheres = append(b.wheres, fmt.Sprintf("%s IS NOT NULL", b.extractName(field)))
-	return b
+	name := b.extractName(field)
+	return b.where(func(p *params) string {
+		return p.name(name) + " IS NOT NULL"
+	})
 }
 
 func (b *QueryBuilder) WhereBetween(field string, value1 interface{}, value2 interface{}) Builder {
-	b.ops = append(b.ops, b.stp)
-	b.wheres = append(b.wheres, fmt.Sprintf("%s BETWEEN %s AND %s", b.extractName(field), Convert(value1), Convert(value2)))
-	return b
+	name := b.extractName(field)
+	return b.where(func(p *params) string {
+		return p.name(name) + " BETWEEN " + p.value(value1) + " AND " + p.value(value2)
+	})
 }
 
 func (b *QueryBuilder) WhereGT(field string, value interface{}) Builder {
-	b.ops = append(b.ops, b.stp)
-	b.wheres = append(b.wheres, fmt.Sprintf("%s > %s", b.extractName(field), Convert(value)))
-	return b
+	return b.compare(field, ">", value)
 }
 func (b *QueryBuilder) WhereGTE(field string, value interface{}) Builder {
-	b.ops = append(b.ops, b.stp)
-	b.wheres = append(b.wheres, fmt.Sprintf("%s >= %s", b.extractName(field), Convert(value)))
-	return b
+	return b.compare(field, ">=", value)
 }
 
 func (b *QueryBuilder) WhereLT(field string, value interface{}) Builder {
-	b.ops = append(b.ops, b.stp)
-	b.wheres = append(b.wheres, fmt.Sprintf("%s < %s", b.extractName(field), Convert(value)))
-	return b
+	return b.compare(field, "<", value)
 }
 func (b *QueryBuilder) WhereLTE(field string, value interface{}) Builder {
-	b.ops = append(b.ops, b.stp)
-	b.wheres = append(b.wheres, fmt.Sprintf("%s <= %s", b.extractName(field), Convert(value)))
-	return b
+	return b.compare(field, "<=", value)
 }
 
 func (b *QueryBuilder) WhereIn(field string, value []interface{}) Builder {
-	b.ops = append(b.ops, b.stp)
-	b.wheres = append(b.wheres, fmt.Sprintf("%s in %s", b.extractName(field), Convert(value)))
-	return b
+	return b.compare(field, "in", value)
 }
 
 func (b *QueryBuilder) WhereInQuery(field string, fn func(b Builder)) Builder {
-	b.ops = append(b.ops, b.stp)
-	bld := &QueryBuilder{
-		typ: SqlTypRead,
-	}
-	fn(bld)
-	b.wheres = append(b.wheres, fmt.Sprintf("%s IN (%s)", b.extractName(field), bld.Query()))
-	return b
+	sub := Subquery(fn)
+	name := b.extractName(field)
+	return b.where(func(p *params) string {
+		return p.name(name) + " IN " + sub.render(p)
+	})
 }
 
 func (b *QueryBuilder) WhereGroup(fn func(b Builder)) Builder {
@@ -244,9 +296,9 @@ func (b *QueryBuilder) WhereGroup(fn func(b Builder)) Builder {
 		typ: SqlTypRead,
 	}
 	fn(builder)
-	b.wheres = append(b.wheres, fmt.Sprintf("(%s)", builder.getWhereClauses(false)))
-	b.ops = append(b.ops, b.stp)
-	return b
+	return b.where(func(p *params) string {
+		return "(" + builder.getWhereClauses(p, false) + ")"
+	})
 }
 
 func (b *QueryBuilder) Or() Builder {
@@ -262,7 +314,7 @@ func (b *QueryBuilder) AndNot() Builder {
 	return b
 }
 
-func (b *QueryBuilder) getWhereClauses(flag bool) string {
+func (b *QueryBuilder) getWhereClauses(p *params, flag bool) string {
 	where := ""
 	ln := len(b.wheres)
 	for i := 0; i < ln; i++ {
@@ -280,8 +332,10 @@ func (b *QueryBuilder) getWhereClauses(flag bool) string {
 				where += "AND NOT "
 				break
 			}
+		} else if b.ops[i] == SqlAndNot {
+			where += "NOT "
 		}
-		where += cls
+		where += cls(p)
 		if i != ln-1 {
 			where += " "
 		}
@@ -289,51 +343,189 @@ func (b *QueryBuilder) getWhereClauses(flag bool) string {
 	return where
 }
 
+// getWhere combines the where clauses with the conditions the builder adds on its own.
+func (b *QueryBuilder) getWhere(p *params) string {
+	var conditions []string
+	if len(b.wheres) > 0 {
+		conditions = append(conditions, b.getWhereClauses(p, false))
+	}
+	if b.typ == SqlTypRead && b.keyset != nil && b.keyset.values != nil {
+		conditions = append(conditions, b.keyset.clause(p, b.orders))
+	}
+	if trashed := b.trashedClause(p); trashed != "" {
+		conditions = append(conditions, trashed)
+	}
+	if f, version, ok := b.lockVersion(); ok {
+		conditions = append(conditions, p.name(f.Column)+" = "+p.value(version.Interface()))
+	}
+	if len(conditions) == 0 {
+		return ""
+	}
+	if len(conditions) > 1 && len(b.wheres) > 0 {
+		conditions[0] = "(" + conditions[0] + ")"
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+func join(p *params, fragments []fragment, sep string) string {
+	out := make([]string, len(fragments))
+	for i, fragment := range fragments {
+		out[i] = fragment(p)
+	}
+	return strings.Join(out, sep)
+}
+
+func (b *QueryBuilder) getTables(p *params) string {
+	tables := make([]string, len(b.tables))
+	for i, table := range b.tables {
+		if sub, ok := b.subqueries[table]; ok {
+			tables[i] = sub.render(p)
+			continue
+		}
+		tables[i] = p.name(table)
+	}
+	return strings.Join(tables, ", ")
+}
+
+func (b *QueryBuilder) getDialect() Dialect {
+	if b.dialect != nil {
+		return b.dialect
+	}
+	return defaultDialect
+}
+
+func (b *QueryBuilder) getContext() context.Context {
+	if b.ctx != nil {
+		return b.ctx
+	}
+	return context.Background()
+}
+
+func (b *QueryBuilder) WithContext(ctx context.Context) Builder {
+	b.ctx = ctx
+	return b
+}
+
+func (b *QueryBuilder) WithDialect(d Dialect) Builder {
+	b.dialect = d
+	return b
+}
+
+// getKeys returns the columns written by an insert or update, in field order for bound structs,
+// in call order for Set and sorted for maps passed to Fill.
+func (b *QueryBuilder) getKeys() []string {
+	if b.keys != nil {
+		return b.keys
+	}
+	keys := make([]string, 0)
+	if len(b.values) > 0 {
+		for key := range *b.values[0] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (b *QueryBuilder) checkColumns() error {
+	keys := b.getKeys()
+	for i, item := range b.values {
+		if len(*item) != len(keys) {
+			return fmt.Errorf("%w: row %d has %d columns, expected %d", ErrColumnMismatch, i, len(*item), len(keys))
+		}
+		for _, key := range keys {
+			if _, ok := (*item)[key]; !ok {
+				return fmt.Errorf("%w: row %d has no %s column", ErrColumnMismatch, i, key)
+			}
+		}
+	}
+	return nil
+}
+
+func (b *QueryBuilder) checkWhere() error {
+	if (b.typ == SqlTypUpdate || b.typ == SqlTypDelete) && len(b.wheres) == 0 && !b.unsafe {
+		return ErrMissingWhere
+	}
+	return nil
+}
+
 func (b *QueryBuilder) Query() (out string) {
+	out = b.render(&params{d: b.getDialect()})
 	if enableLog {
-		defer func() {
-			log.Println(out)
-		}()
+		log.Println(out)
 	}
+	return
+}
+
+// QueryWithArgs renders the statement with bound arguments, what the dialect can't express is
+// reported by GetError.
+func (b *QueryBuilder) QueryWithArgs() (out string, args []interface{}) {
+	out, args, err := b.build()
+	if err != nil {
+		b.err = err
+		return "", nil
+	}
+	return
+}
+
+// build renders the statement with bound arguments and reports what the dialect can't express,
+// or an error the builder already holds.
+func (b *QueryBuilder) build() (out string, args []interface{}, err error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	if err = b.checkWhere(); err != nil {
+		return
+	}
+	if err = b.checkColumns(); err != nil {
+		return
+	}
+	p := &params{d: b.getDialect(), bind: true}
+	out = b.render(p)
+	args = p.args
+	err = p.err
+	if enableLog {
+		log.Println(out, args)
+	}
+	return
+}
+
+func (b *QueryBuilder) render(p *params) (out string) {
 	if b.typ == SqlTypRead {
 		columns := "*"
 		if len(b.columns) > 0 {
-			columns = strings.Join(b.columns, ", ")
-		}
-		orderBy := ""
-		if len(b.orders) > 0 {
-			orderBy = " ORDER BY " + strings.Join(b.orders, ", ")
-		}
-		where := ""
-		if len(b.wheres) > 0 {
-			where = " WHERE 1 " + b.getWhereClauses(true)
+			columns = join(p, b.columns, ", ")
 		}
+		tables := b.getTables(p)
 		joins := ""
 		if len(b.joins) > 0 {
-			joins = " " + strings.Join(b.joins, " ")
+			joins = " " + join(p, b.joins, " ")
 		}
+		where := b.getWhere(p)
 		groupBy := ""
 		if len(b.groups) > 0 {
-			groupBy = " GROUP BY " + strings.Join(b.groups, ", ")
-			if b.having != "" {
-				groupBy += " HAVING " + b.having
+			groups := make([]string, len(b.groups))
+			for i, group := range b.groups {
+				groups[i] = p.name(group)
+			}
+			groupBy = " GROUP BY " + strings.Join(groups, ", ")
+			if b.having != nil && len(b.having.wheres) > 0 {
+				groupBy += " HAVING " + b.having.getWhereClauses(p, false)
 			}
 		}
-		limit := ""
-		if b.limit > 0 {
-			limit = fmt.Sprintf(" LIMIT %v", b.limit)
-		}
-		offset := ""
-		if b.offset > 0 {
-			offset = fmt.Sprintf(" OFFSET %v", b.offset)
+		orderBy := ""
+		if len(b.orders) > 0 {
+			orders := make([]string, len(b.orders))
+			for i, o := range b.orders {
+				orders[i] = o.render(p)
+			}
+			orderBy = " ORDER BY " + strings.Join(orders, ", ")
 		}
-		query := "SELECT " + columns + " FROM " + strings.Join(b.tables, ", ") + joins + where + groupBy + orderBy + limit + offset
+		top, tail := p.d.Paginate(b.limit, b.offset, len(b.orders) > 0)
+		query := "SELECT " + top + columns + " FROM " + tables + joins + where + groupBy + orderBy + tail
 		out = strings.Trim(query, " ")
 	} else if b.typ == SqlTypCreate {
-		keys := make([]string, 0)
-		for key := range *b.values[0] {
-			keys = append(keys, key)
-		}
+		keys := b.getKeys()
 		ln := len(keys)
 
 		stm := make([]string, 0)
@@ -343,7 +535,7 @@ func (b *QueryBuilder) Query() (out string) {
 			i := 0
 			for _, key := range keys {
 				itm := (*item)[key]
-				values += Convert(itm)
+				values += p.value(itm)
 				if i != ln-1 {
 					values += ", "
 				}
@@ -351,54 +543,67 @@ func (b *QueryBuilder) Query() (out string) {
 			}
 			stm = append(stm, "("+values+")")
 		}
-		out = "INSERT INTO " + b.tables[0] + "(" + strings.Join(keys, ", ") + ") VALUES" + strings.Join(stm, ", ")
+		names := make([]string, ln)
+		for i, key := range keys {
+			names[i] = p.name(key)
+		}
+		output, returning := b.getReturning(p)
+		out = "INSERT INTO " + p.name(b.tables[0]) + "(" + strings.Join(names, ", ") + ")" + output + " VALUES" + strings.Join(stm, ", ")
+		if b.upsert != nil {
+			out += b.upsert.render(p, b.tables[0], keys, b.model)
+		}
+		out += returning
 	} else if b.typ == SqlTypUpdate {
-		item := b.values[0]
+		keys := b.getKeys()
 		values := ""
-		i := 0
-		ln := len(*item)
-		for key, value := range *item {
-			values += key + "=" + Convert(value)
+		ln := len(keys)
+		for i, key := range keys {
+			values += p.name(key) + "=" + p.value((*b.values[0])[key])
 			if i != ln-1 {
 				values += ", "
 			}
-			i++
 		}
 
-		where := ""
-		if len(b.wheres) > 0 {
-			where = " WHERE 1 " + b.getWhereClauses(true)
-		}
+		where := b.getWhere(p)
 
 		set := ""
 		if len(b.values) > 0 {
 			set = " SET " + values
 		}
+		if f, _, ok := b.lockVersion(); ok {
+			bump := p.name(f.Column) + "=" + p.name(f.Column) + " + 1"
+			if set == "" || len(keys) == 0 {
+				set = " SET " + bump
+			} else {
+				set += ", " + bump
+			}
+		}
 
-		out = "UPDATE " + strings.Join(b.tables, ", ") + set + where
+		output, returning := b.getReturning(p)
+		out = "UPDATE " + b.getTables(p) + set + output + where + returning
 	} else if b.typ == SqlTypDelete {
-		out = "DELETE FROM " + strings.Join(b.tables, ", ") + " WHERE " + b.getWhereClauses(false)
+		if f := b.softDelete(); f != nil {
+			set := " SET " + p.name(f.Column) + "=" + p.value(timeValue(f, clock()))
+			output, returning := b.getReturning(p)
+			out = "UPDATE " + b.getTables(p) + set + output + b.getWhere(p) + returning
+			return
+		}
+		output, returning := b.getReturning(p)
+		out = "DELETE FROM " + b.getTables(p) + output + b.getWhere(p) + returning
 	} else if b.typ == SqlTypCustom {
-		out = b.customQuery
+		out = p.custom(b.customQuery, b.customArgs)
 	}
 	return
 }
 
-func (b *QueryBuilder) Use(a interface{}) Builder {
-	b.u = a
-	tx, ok := a.(*sql.Tx)
-	if ok {
-		b.tx = tx
-		b.db = nil
-	} else {
-		db, ok := a.(*sql.DB)
-		if ok {
-			b.tx = nil
-			b.db = db
-		} else {
-			panic("wrong db provider used")
-		}
-	}
+// Unsafe allows an update or delete without a where clause to touch every row of the table.
+func (b *QueryBuilder) Unsafe() Builder {
+	b.unsafe = true
+	return b
+}
+
+func (b *QueryBuilder) Use(e Executor) Builder {
+	b.exec = e
 	return b
 }
 
@@ -414,30 +619,50 @@ func (b *QueryBuilder) Offset(offset int64) Builder {
 func (b *QueryBuilder) First(o interface{}) Builder {
 	b.limit = 1
 	b.Scan(o)
-	if b.fln < 1 {
-		b.err = fmt.Errorf("no row found")
+	if b.err == nil && b.fln < 1 {
+		b.err = ErrNoRows
 	}
 	return b
 }
 
 func (b *QueryBuilder) query() (*sql.Rows, error) {
-	if b.db == nil && b.tx == nil {
-		panic("db driver not defined")
+	if b.exec == nil {
+		return nil, ErrNoExecutor
 	}
-	if b.db != nil {
-		return b.db.Query(b.Query())
-	} else {
-		return b.tx.Query(b.Query())
+	query, args, err := b.build()
+	if err != nil {
+		return nil, err
 	}
+	rows, err := b.exec.QueryContext(b.getContext(), query, args...)
+	if err != nil {
+		return nil, b.queryError(query, args, err)
+	}
+	return rows, nil
 }
 
+// Count counts the rows the select would return, an ORDER BY without a LIMIT or OFFSET is left out since
+// SQL Server refuses one in a subquery and it can't change the count.
 func (b *QueryBuilder) Count(count *int64) Builder {
+	if b.err != nil {
+		return b
+	}
 	type LenObj struct {
-		Len int64 `json:"len"`
+		Len int64 `gql:"len"`
 	}
 	var obj LenObj
-	a := Read("(" + b.Query() + ") a").Columns("COUNT(*) len").Use(b.u).Scan(&obj)
-	b.err = a.GetError()
+	c := &QueryBuilder{
+		typ:     SqlTypRead,
+		dialect: b.dialect,
+		ctx:     b.ctx,
+		exec:    b.exec,
+	}
+	orders := b.orders
+	if b.limit == 0 && b.offset == 0 {
+		b.orders = nil
+	}
+	c.FromSub(b, "a").Columns(Sql("COUNT(*) len")).Scan(&obj)
+	b.orders = orders
+	b.err = c.GetError()
 	*count = obj.Len
 	return b
 }
@@ -462,13 +687,27 @@ func (b *QueryBuilder) Paginate(page int64, take int64) (out Builder) {
 }
 func (b *QueryBuilder) Set(key string, val interface{}) (out Builder) {
 	out = b
+	name := b.extractName(key)
+	if b.obj != nil {
+		b.sets = append(b.sets, assignment{column: name, value: val})
+	}
+	b.set(name, val)
+	return
+}
+
+func (b *QueryBuilder) set(name string, val interface{}) {
 	if len(b.values) == 0 {
 		b.values = []*OBJ{{}}
+		b.keys = []string{}
+	}
+	if b.keys != nil && !some(b.keys, func(key string) bool {
+		return key == name
+	}) {
+		b.keys = append(b.keys, name)
 	}
 	for _, value := range b.values {
-		(*value)[b.extractName(key)] = val
+		(*value)[name] = val
 	}
-	return
 }
 func some(stack []string, check func(key string) bool) bool {
 	for _, key := range stack {
@@ -504,16 +743,8 @@ func checkEqual(fieldName, name string) bool {
 	return true
 }
 
-func (b *QueryBuilder) getStructFields(elemType reflect.Type, mode int, keys ...string) (out map[string]int) {
-	out = make(map[string]int)
-	b.fldTag = make(map[string]string)
-
-	fln := elemType.NumField()
-	for j := 0; j < fln; j++ {
-
-		field := elemType.Field(j)
-		tag := field.Tag.Get("gql")
-		b.fldTag[field.Name] = tag
+func (b *QueryBuilder) getStructFields(m *ModelInfo, mode int, keys ...string) (out []*FieldInfo) {
+	for _, field := range m.Fields {
 		allow := true
 
 		if mode == 1 { // only
@@ -531,71 +762,84 @@ func (b *QueryBuilder) getStructFields(elemType reflect.Type, mode int, keys ...
 				})
 			}
 		}
-		if tag != "-" && tag != "" && allow && tag != "id" {
-			out[tag] = j
-		} else {
-			out[tag] = -1
+		if allow && field.writable(b.typ) {
+			out = append(out, field)
 		}
 	}
 	return
 }
 
-func (b *QueryBuilder) extractTags(elemType reflect.Type) {
-	b.fldTag = make(map[string]string)
-	fln := elemType.NumField()
-	for j := 0; j < fln; j++ {
-		field := elemType.Field(j)
-		tag := field.Tag.Get("gql")
-		b.fldTag[field.Name] = tag
+func (b *QueryBuilder) useModel(m *ModelInfo) {
+	b.model = m
+	if len(b.tables) == 0 || b.tables[0] == "" {
+		b.tables = []string{m.Table}
 	}
 }
+
 func (b *QueryBuilder) Model(ifc interface{}) (out Builder) {
 	out = b
-	tf := reflect.TypeOf(ifc).Elem()
-	if tf.Kind() != reflect.Struct {
-		tf = tf.Elem()
+	m, err := GetModel(ifc)
+	if err != nil {
+		b.err = err
+		return
 	}
-	b.extractTags(tf)
+	b.useModel(m)
 	return
 }
 func (b *QueryBuilder) bind(mode int, o interface{}, keys ...string) (out Builder) {
 	out = b
 	b.obj = o
+	b.bindMode = mode
+	b.bindKeys = keys
+	b.sets = nil
 
 	var err error
 	defer func() {
-		if p := recover(); p != nil {
-			b.err = fmt.Errorf("%v", p)
-			panic(p)
-		} else if err != nil {
+		if err != nil {
 			b.err = err
 		}
 	}()
 
-	vf := reflect.ValueOf(o).Elem()
-	tf := reflect.TypeOf(o).Elem()
+	if err = checkTarget(o, false); err != nil {
+		return
+	}
+	var m *ModelInfo
+	m, err = getModel(reflect.TypeOf(o).Elem())
+	if err != nil {
+		return
+	}
+	b.useModel(m)
+	b.readBound()
+	return
+}
 
-	if tf.Kind() == reflect.Slice {
-		elemType := tf.Elem()
-		if elemType.Kind() != reflect.Struct {
-			elemType = elemType.Elem()
+// readBound takes the columns to write from the bound struct or slice and replays the Set calls made
+// after Bind, Run reads them again once the before hooks changed the rows.
+func (b *QueryBuilder) readBound() {
+	vf := reflect.ValueOf(b.obj).Elem()
+	tf := vf.Type()
+	fields := b.getStructFields(b.model, b.bindMode, b.bindKeys...)
+	defer func() {
+		for _, s := range b.sets {
+			b.set(s.column, s.value)
 		}
-		structFields := b.getStructFields(elemType, mode, keys...)
+	}()
 
+	b.keys = make([]string, 0, len(fields))
+	if tf.Kind() == reflect.Slice {
+		for _, field := range fields {
+			b.keys = append(b.keys, field.Column)
+		}
 		b.values = make([]*OBJ, 0)
 		ln := vf.Len()
+		now := clock()
 		for i := 0; i < ln; i++ {
-			val := vf.Index(i)
-			if val.Kind() != reflect.Struct {
-				val = val.Elem()
-			}
+			val := reflect.Indirect(vf.Index(i))
+			b.stampFields(val, fields, now)
 			data := make(OBJ)
-			for key, j := range structFields {
-				if j > -1 {
-					value := val.Field(j)
-					if value.CanInterface() {
-						data[key] = value.Interface()
-					}
+			for _, field := range fields {
+				if value, ok := fieldOf(val, field.Index, false); ok {
+					data[field.Column] = value.Interface()
 				}
 			}
 			b.values = append(b.values, &data)
@@ -603,36 +847,34 @@ func (b *QueryBuilder) bind(mode int, o interface{}, keys ...string) (out Builde
 		return
 	}
 	if tf.Kind() != reflect.Struct {
-		tf = tf.Elem()
 		vf = vf.Elem()
 	}
 
-	structFields := b.getStructFields(tf, mode, keys...)
-
+	b.stampFields(vf, fields, clock())
+	// omitempty only applies to a single row, every row of a bulk insert needs the same columns
 	data := make(OBJ)
-	for key, j := range structFields {
-		if j > -1 {
-			value := vf.Field(j)
-			if value.CanInterface() {
-				data[key] = value.Interface()
-			}
+	for _, field := range fields {
+		value, ok := fieldOf(vf, field.Index, false)
+		if !ok || (field.OmitEmpty && value.IsZero()) {
+			continue
 		}
+		data[field.Column] = value.Interface()
+		b.keys = append(b.keys, field.Column)
 	}
 	b.values = []*OBJ{&data}
-	return
 }
 func (b *QueryBuilder) Scan(o interface{}) (out Builder) {
 	out = b
 	var err error
 	defer func() {
-		if p := recover(); p != nil {
-			b.err = fmt.Errorf("%v", p)
-			panic(p)
-		} else if err != nil {
+		if err != nil {
 			b.err = err
 		}
 	}()
 
+	if err = checkTarget(o, true); err != nil {
+		return
+	}
 	tf := reflect.TypeOf(o).Elem()
 	vf := reflect.ValueOf(o).Elem()
 
@@ -643,50 +885,38 @@ func (b *QueryBuilder) Scan(o interface{}) (out Builder) {
 			elem = elem.Elem()
 			stc = false
 		}
-		pairs := make(map[string]string)
-		ln := elem.NumField()
-		for i := 0; i < ln; i++ {
-			field := elem.Field(i)
-			tag := field.Tag.Get("gql")
-			if tag != "-" && tag != "" {
-				pairs[tag] = field.Name
-			} else {
-				pairs[field.Name] = field.Name
-			}
+		var model *ModelInfo
+		model, err = getModel(elem)
+		if err != nil {
+			return
 		}
 		var rows *sql.Rows
 		rows, err = b.query()
 		if err != nil {
 			return
 		}
+		defer rows.Close()
+
+		var data []string
+		data, err = rows.Columns()
+		if err != nil {
+			return
+		}
+		plan := model.plan(data)
+		var ifc []interface{}
 
 		vf.Set(reflect.MakeSlice(tf, 0, 0))
 		b.fln = 0
 		for rows.Next() {
-			var data []string
-			data, err = rows.Columns()
-			if err != nil {
-				rows.Close()
-				return
-			}
-
 			val := reflect.New(elem)
-			el := val.Elem()
-			ifc := make([]interface{}, len(data))
-			for i, str := range data {
-				op := pairs[str]
-				if op != "" {
-					obj := el.FieldByName(op).Addr().Interface()
-					ifc[i] = obj
-				} else {
-					var obj interface{}
-					ifc[i] = &obj
-				}
-			}
+			ifc = plan.targets(val.Elem(), ifc)
 			err = rows.Scan(ifc...)
 			if err != nil {
 				return
 			}
+			if err = afterFind(b.getContext(), val.Interface()); err != nil {
+				return
+			}
 			if stc {
 				vf.Set(reflect.Append(vf, val.Elem()))
 			} else {
@@ -694,6 +924,17 @@ func (b *QueryBuilder) Scan(o interface{}) (out Builder) {
 			}
 			b.fln++
 		}
+		err = rows.Err()
+		if err == nil && b.keyset != nil {
+			err = b.keyset.capture(vf, model, b.orders, b.fln >= b.limit)
+		}
+		if err == nil && len(b.preloads) > 0 {
+			parents := make([]reflect.Value, vf.Len())
+			for i := range parents {
+				parents[i] = reflect.Indirect(vf.Index(i))
+			}
+			err = b.preload(model, parents)
+		}
 
 	} else {
 		elem := tf
@@ -701,51 +942,44 @@ func (b *QueryBuilder) Scan(o interface{}) (out Builder) {
 
 		if elem.Kind() != reflect.Struct {
 			elem = elem.Elem()
+			if val.IsNil() {
+				val.Set(reflect.New(elem))
+			}
 			val = val.Elem()
 		}
-		pairs := make(map[string]string)
-		ln := elem.NumField()
-		for i := 0; i < ln; i++ {
-			field := elem.Field(i)
-			tag := field.Tag.Get("gql")
-			if tag != "-" && tag != "" {
-				pairs[tag] = field.Name
-			} else {
-				pairs[field.Name] = field.Name
-			}
+		var model *ModelInfo
+		model, err = getModel(elem)
+		if err != nil {
+			return
 		}
 		var rows *sql.Rows
 		rows, err = b.query()
 		if err != nil {
 			return
 		}
+		defer rows.Close()
 		b.fln = 0
 		for rows.Next() {
 			var data []string
 			data, err = rows.Columns()
 			if err != nil {
-				rows.Close()
 				return
 			}
-			ifc := make([]interface{}, len(data))
-			for i, str := range data {
-				op := pairs[str]
-				if op != "" {
-					obj := val.FieldByName(op).Addr().Interface()
-					ifc[i] = obj
-				} else {
-					var obj interface{}
-					ifc[i] = &obj
-				}
-			}
-			err = rows.Scan(ifc...)
+			err = rows.Scan(model.plan(data).targets(val, nil)...)
 			if err != nil {
 				return
 			}
+			if err = afterFind(b.getContext(), val.Addr().Interface()); err != nil {
+				return
+			}
 			b.fln++
-			rows.Close()
+			if len(b.preloads) > 0 {
+				rows.Close()
+				err = b.preload(model, []reflect.Value{val})
+			}
 			return
 		}
+		err = rows.Err()
 	}
 	return
 }
@@ -758,6 +992,10 @@ func (b *QueryBuilder) Chunk(length int64, callback func(Scan func(o interface{}
 	out = b
 	var offset int64 = 0
 	for {
+		if err := b.getContext().Err(); err != nil {
+			b.err = err
+			return
+		}
 		b.Top(length)
 		b.Offset(offset)
 		callback(b.Scan)
@@ -779,49 +1017,86 @@ func (b *QueryBuilder) Run() (out Builder) {
 	out = b
 	var err error
 	defer func() {
-		if p := recover(); p != nil {
-			b.err = fmt.Errorf("%v", p)
-			panic(p)
-		} else if err != nil {
+		if err != nil {
 			b.err = err
 		}
 	}()
 
-	if b.db == nil && b.tx == nil {
-		panic("db driver not defined")
+	if b.err != nil {
+		return
+	}
+	if b.exec == nil {
+		err = ErrNoExecutor
+		return
+	}
+	if err = b.checkWhere(); err != nil {
+		return
+	}
+	if err = b.beforeHooks(); err != nil {
+		return
+	}
+	if b.obj != nil && (b.typ == SqlTypCreate || b.typ == SqlTypUpdate) {
+		b.readBound()
+	}
+	if b.typ == SqlTypCreate || b.typ == SqlTypUpdate {
+		b.stampTimes()
+	}
+	if err = b.execute(); err != nil {
+		return
+	}
+	if err = b.bumpVersion(); err != nil {
+		return
+	}
+	err = b.afterHooks()
+	return
+}
+
+func (b *QueryBuilder) execute() (err error) {
+	d := b.getDialect()
+	b.returnInsertedIds(d)
+	_, _, returning := d.Returning(b.typ, nil)
+	if len(b.returning) > 0 && returning {
+		return b.runReturning()
+	}
+	if len(b.returning) > 0 {
+		if err = b.checkFallback(d); err != nil {
+			return
+		}
+	}
+	var keys []interface{}
+	if len(b.returning) > 0 && b.typ == SqlTypDelete {
+		if _, err = b.selectReturning(""); err != nil {
+			return
+		}
+	} else if len(b.returning) > 0 && b.typ == SqlTypUpdate {
+		if keys, err = b.selectKeys(); err != nil {
+			return
+		}
 	}
 
 	var a sql.Result
 
-	if b.db != nil {
-		a, err = b.db.Exec(b.Query())
-	} else {
-		a, err = b.tx.Exec(b.Query())
+	var query string
+	var args []interface{}
+	query, args, err = b.build()
+	if err != nil {
+		return
 	}
+	a, err = b.exec.ExecContext(b.getContext(), query, args...)
 	if err != nil {
+		return b.queryError(query, args, err)
+	}
+	b.rowsAffected, err = a.RowsAffected()
+	if err == nil && len(b.returning) > 0 && b.typ == SqlTypUpdate {
+		_, err = b.selectReturning(b.primaryKey(), keys...)
+	}
+	if err != nil || b.typ != SqlTypCreate || !d.SupportsLastInsertId() {
 		return
 	}
 	b.lastInsertedId, err = a.LastInsertId()
 	if err != nil {
 		return
 	}
-	if b.obj != nil && b.typ == SqlTypCreate {
-		vf := reflect.ValueOf(b.obj).Elem()
-		if vf.Kind() != reflect.Struct && vf.Kind() != reflect.Slice {
-			vf = vf.Elem()
-		}
-		if vf.Kind() == reflect.Struct {
-			ln := vf.NumField()
-			for i := 0; i < ln; i++ {
-				val := vf.Field(i)
-				tags := vf.Type().Field(i).Tag
-				if tags.Get("gql") == "id" {
-					val.Set(reflect.ValueOf(b.lastInsertedId))
-					break
-				}
-			}
-		}
-	}
-	b.rowsAffected, err = a.RowsAffected()
+	err = b.fillInsertedIds()
 	return
 }
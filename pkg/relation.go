@@ -0,0 +1,273 @@
+package gql
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	BelongsTo  = "belongsto"
+	HasOne     = "hasone"
+	HasMany    = "hasmany"
+	ManyToMany = "manytomany"
+)
+
+// Relation is a struct field tagged with one of the relation kinds, e.g.
+//
+//	Customer *Customer `gql:"-,belongsto,fk=customer_id"`
+//	Items    []Item    `gql:"-,hasmany,fk=order_id"`
+//	Tags     []*Tag    `gql:"-,manytomany,jointable=order_tags,fk=order_id,ref=tag_id"`
+//
+// For belongsto fk is the column of the model and ref the referenced column of the related model, for
+// hasone and hasmany fk is the column of the related model and ref the referenced column of the model.
+// For manytomany both name columns of the join table, the models are joined by their primary keys.
+type Relation struct {
+	Name       string
+	Kind       string
+	Index      []int
+	Type       reflect.Type
+	Elem       reflect.Type
+	ForeignKey string
+	References string
+	JoinTable  string
+}
+
+func newRelation(field reflect.StructField, index []int, options map[string]string) *Relation {
+	rel := &Relation{
+		Name:       field.Name,
+		Index:      index,
+		Type:       field.Type,
+		ForeignKey: options["fk"],
+		References: options["ref"],
+		JoinTable:  options["jointable"],
+	}
+	for _, kind := range []string{BelongsTo, HasOne, HasMany, ManyToMany} {
+		if _, ok := options[kind]; ok {
+			rel.Kind = kind
+		}
+	}
+	if rel.Kind == "" {
+		return nil
+	}
+	rel.Elem = field.Type
+	for rel.Elem.Kind() == reflect.Ptr || rel.Elem.Kind() == reflect.Slice {
+		rel.Elem = rel.Elem.Elem()
+	}
+	return rel
+}
+
+// Preload loads the named relations of the scanned rows with one query per relation, nested relations
+// are separated by dots as in "Items.Product".
+func (b *QueryBuilder) Preload(relations ...string) Builder {
+	b.preloads = append(b.preloads, relations...)
+	return b
+}
+
+func (b *QueryBuilder) related(table string, m *ModelInfo) *QueryBuilder {
+	return &QueryBuilder{
+		typ:     SqlTypRead,
+		tables:  []string{table},
+		model:   m,
+		dialect: b.dialect,
+		ctx:     b.ctx,
+		exec:    b.exec,
+	}
+}
+
+func (b *QueryBuilder) preload(m *ModelInfo, rows []reflect.Value) error {
+	var names []string
+	nested := make(map[string][]string)
+	for _, path := range b.preloads {
+		name, rest := path, ""
+		if i := strings.Index(path, "."); i > -1 {
+			name, rest = path[:i], path[i+1:]
+		}
+		if _, ok := nested[name]; !ok {
+			names = append(names, name)
+			nested[name] = nil
+		}
+		if rest != "" {
+			nested[name] = append(nested[name], rest)
+		}
+	}
+	for _, name := range names {
+		rel := m.Relation(name)
+		if rel == nil {
+			return fmt.Errorf("gql: %s has no relation %s", m.Type, name)
+		}
+		if err := b.load(m, rel, rows, nested[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *QueryBuilder) load(m *ModelInfo, rel *Relation, rows []reflect.Value, nested []string) error {
+	target, err := getModel(rel.Elem)
+	if err != nil {
+		return err
+	}
+	// owner is the column read from the rows, match the column of the related rows it's compared to
+	var owner, match string
+	switch rel.Kind {
+	case BelongsTo:
+		owner, match = rel.ForeignKey, rel.References
+		if owner == "" {
+			owner = snakeCase(rel.Name) + "_id"
+		}
+		if match == "" {
+			match = target.key()
+		}
+	case HasOne, HasMany:
+		owner, match = rel.References, rel.ForeignKey
+		if owner == "" {
+			owner = m.key()
+		}
+		if match == "" {
+			match = snakeCase(m.Type.Name()) + "_id"
+		}
+	case ManyToMany:
+		owner, match = m.key(), target.key()
+	}
+	ownerField, matchField := m.Column(owner), target.Column(match)
+	if ownerField == nil {
+		return fmt.Errorf("gql: %s has no column %s", m.Type, owner)
+	}
+	if matchField == nil {
+		return fmt.Errorf("gql: %s has no column %s", target.Type, match)
+	}
+
+	keys := make([]interface{}, len(rows))
+	var lookup []interface{}
+	seen := make(map[interface{}]bool)
+	for i, row := range rows {
+		value, ok := fieldOf(row, ownerField.Index, false)
+		if !ok {
+			continue
+		}
+		keys[i] = keyOf(value)
+		if keys[i] != nil && !seen[keys[i]] {
+			seen[keys[i]] = true
+			lookup = append(lookup, keys[i])
+		}
+	}
+	if len(lookup) == 0 {
+		return nil
+	}
+
+	var pairs map[interface{}][]interface{}
+	if rel.Kind == ManyToMany {
+		if pairs, lookup, err = b.joinTable(m, target, rel, ownerField, matchField, lookup); err != nil || len(lookup) == 0 {
+			return err
+		}
+	}
+
+	list := reflect.New(reflect.SliceOf(rel.Elem))
+	q := b.related(target.Table, target)
+	q.WhereIn(match, lookup)
+	q.preloads = nested
+	if err = q.Scan(list.Interface()).GetError(); err != nil {
+		return err
+	}
+	index := make(map[interface{}][]reflect.Value)
+	for i := 0; i < list.Elem().Len(); i++ {
+		child := list.Elem().Index(i)
+		if value, ok := fieldOf(child, matchField.Index, false); ok {
+			key := keyOf(value)
+			index[key] = append(index[key], child)
+		}
+	}
+
+	for i, row := range rows {
+		if keys[i] == nil {
+			continue
+		}
+		related := index[keys[i]]
+		if pairs != nil {
+			related = nil
+			for _, key := range pairs[keys[i]] {
+				related = append(related, index[key]...)
+			}
+		}
+		if field, ok := fieldOf(row, rel.Index, true); ok {
+			assign(field, related)
+		}
+	}
+	return nil
+}
+
+// joinTable reads the join table of a many to many relation, it returns the related keys of every key
+// and all of the related keys.
+func (b *QueryBuilder) joinTable(m, target *ModelInfo, rel *Relation, ownerField, matchField *FieldInfo, lookup []interface{}) (map[interface{}][]interface{}, []interface{}, error) {
+	fk, ref := rel.ForeignKey, rel.References
+	if fk == "" {
+		fk = snakeCase(m.Type.Name()) + "_id"
+	}
+	if ref == "" {
+		ref = snakeCase(target.Type.Name()) + "_id"
+	}
+	table := rel.JoinTable
+	if table == "" {
+		table = snakeCase(m.Type.Name()) + "_" + target.Table
+	}
+	q := b.related(table, nil)
+	q.Columns(fk, ref).WhereIn(fk, lookup)
+	rows, err := q.query()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	pairs := make(map[interface{}][]interface{})
+	var related []interface{}
+	seen := make(map[interface{}]bool)
+	for rows.Next() {
+		from, to := reflect.New(ownerField.Type), reflect.New(matchField.Type)
+		if err = rows.Scan(from.Interface(), to.Interface()); err != nil {
+			return nil, nil, err
+		}
+		key, value := keyOf(from.Elem()), keyOf(to.Elem())
+		pairs[key] = append(pairs[key], value)
+		if !seen[value] {
+			seen[value] = true
+			related = append(related, value)
+		}
+	}
+	return pairs, related, rows.Err()
+}
+
+// keyOf normalizes a key column so that e.g. an int and a sql.NullInt64 of the same value match.
+func keyOf(v reflect.Value) interface{} {
+	value, err := driver.DefaultParameterConverter.ConvertValue(v.Interface())
+	if err != nil {
+		return v.Interface()
+	}
+	if data, ok := value.([]byte); ok {
+		return string(data)
+	}
+	return value
+}
+
+func assign(field reflect.Value, related []reflect.Value) {
+	elem := func(t reflect.Type, v reflect.Value) reflect.Value {
+		if t.Kind() == reflect.Ptr {
+			return v.Addr()
+		}
+		return v
+	}
+	if field.Kind() == reflect.Slice {
+		out := reflect.MakeSlice(field.Type(), 0, len(related))
+		for _, v := range related {
+			out = reflect.Append(out, elem(field.Type().Elem(), v))
+		}
+		field.Set(out)
+		return
+	}
+	if len(related) == 0 {
+		field.Set(reflect.Zero(field.Type()))
+		return
+	}
+	field.Set(elem(field.Type(), related[0]))
+}
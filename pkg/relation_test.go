@@ -0,0 +1,106 @@
+package gql
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+type relCustomer struct {
+	ID int64 `gql:"id"`
+}
+
+type relItem struct {
+	ID      int64 `gql:"id"`
+	OrderID int64 `gql:"order_id"`
+}
+
+type relOrder struct {
+	ID         int64        `gql:"id"`
+	CustomerID int64        `gql:"customer_id"`
+	Customer   *relCustomer `gql:"-,belongsto"`
+	Items      []relItem    `gql:"-,hasmany,fk=order_id"`
+	Tags       []*relItem   `gql:"-,manytomany,jointable=order_tags,fk=order_id,ref=tag_id"`
+	Ignored    string       `gql:"-"`
+}
+
+func TestRelations(t *testing.T) {
+	m, err := GetModel(&relOrder{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Relations) != 3 {
+		t.Fatalf("%d relations", len(m.Relations))
+	}
+	cases := []struct {
+		name, kind, fk, ref, join string
+	}{
+		{"Customer", BelongsTo, "", "", ""},
+		{"Items", HasMany, "order_id", "", ""},
+		{"Tags", ManyToMany, "order_id", "tag_id", "order_tags"},
+	}
+	for _, c := range cases {
+		rel := m.Relation(c.name)
+		if rel == nil || rel.Kind != c.kind || rel.ForeignKey != c.fk || rel.References != c.ref || rel.JoinTable != c.join {
+			t.Errorf("%s: %+v", c.name, rel)
+			continue
+		}
+		if rel.Elem.Kind().String() != "struct" {
+			t.Errorf("%s: element %s", c.name, rel.Elem)
+		}
+	}
+	if m.Column("customer") != nil || m.Field("Ignored") != nil && m.Field("Ignored").Tagged {
+		t.Error("relations or ignored fields became columns")
+	}
+	if err = Read("").Model(&relOrder{}).Preload("Missing").(*QueryBuilder).preload(m, nil); err == nil {
+		t.Error("preloaded an unknown relation")
+	}
+}
+
+func TestPreload(t *testing.T) {
+	db, f := newFakeDB(t,
+		fakeAnswer{prefix: "SELECT * FROM `rel_orders`", columns: []string{"id", "customer_id"}, rows: [][]driver.Value{{1, 10}, {2, 10}, {3, 11}}},
+		fakeAnswer{prefix: "SELECT * FROM `rel_customers`", columns: []string{"id"}, rows: [][]driver.Value{{11}, {10}}},
+		fakeAnswer{prefix: "SELECT * FROM `rel_items` WHERE `order_id`", columns: []string{"id", "order_id"}, rows: [][]driver.Value{{100, 1}, {102, 3}, {101, 1}}},
+		fakeAnswer{prefix: "SELECT `order_id`", columns: []string{"order_id", "tag_id"}, rows: [][]driver.Value{{1, 200}, {3, 200}, {3, 201}}},
+		fakeAnswer{prefix: "SELECT * FROM `rel_items` WHERE `id`", columns: []string{"id", "order_id"}, rows: [][]driver.Value{{201, 0}, {200, 0}}},
+	)
+	var orders []relOrder
+	if err := Read("").Model(&relOrder{}).Preload("Customer", "Items", "Tags").Use(db).Scan(&orders).GetError(); err != nil {
+		t.Fatal(err)
+	}
+	checkStatements(t, f,
+		"SELECT * FROM `rel_orders`",
+		"SELECT * FROM `rel_customers` WHERE `id` in (?,?)",
+		"SELECT * FROM `rel_items` WHERE `order_id` in (?,?,?)",
+		"SELECT `order_id`, `tag_id` FROM `order_tags` WHERE `order_id` in (?,?,?)",
+		"SELECT * FROM `rel_items` WHERE `id` in (?,?)",
+	)
+	ids := func(items []relItem) (out []int64) {
+		for _, item := range items {
+			out = append(out, item.ID)
+		}
+		return
+	}
+	tags := func(items []*relItem) (out []int64) {
+		for _, item := range items {
+			out = append(out, item.ID)
+		}
+		return
+	}
+	want := []struct {
+		customer int64
+		items    []int64
+		tags     []int64
+	}{
+		{10, []int64{100, 101}, []int64{200}},
+		{10, nil, nil},
+		{11, []int64{102}, []int64{200, 201}},
+	}
+	for i, w := range want {
+		o := orders[i]
+		if o.Customer == nil || o.Customer.ID != w.customer || !reflect.DeepEqual(ids(o.Items), w.items) || !reflect.DeepEqual(tags(o.Tags), w.tags) {
+			t.Errorf("order %d: customer %+v, items %v, tags %v", o.ID, o.Customer, ids(o.Items), tags(o.Tags))
+		}
+	}
+}
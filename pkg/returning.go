@@ -0,0 +1,311 @@
+package gql
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// Returning scans the given columns of the written rows back into the bound struct or slice. The rows
+// of a multi row insert are matched to the elements by a unique column the insert writes, the primary
+// key or one tagged unique, since neither RETURNING nor OUTPUT promise the order of the VALUES and rows
+// skipped on a conflict don't come back. Other rows go to the elements in order. Dialects without
+// RETURNING or OUTPUT fall back to a select: by the inserted ids, by the where clause before a delete,
+// by the primary keys the where clause matched before an update. Statements the fallback can't serve
+// fail with ErrNoReturning.
+func (b *QueryBuilder) Returning(columns ...string) Builder {
+	b.returning = nil
+	for _, column := range columns {
+		b.returning = append(b.returning, b.extractName(column))
+	}
+	return b
+}
+
+func (b *QueryBuilder) getReturning(p *params) (output string, tail string) {
+	if len(b.returning) == 0 {
+		return
+	}
+	names := make([]string, len(b.returning))
+	for i, column := range b.returning {
+		names[i] = p.name(column)
+	}
+	typ := b.typ
+	if typ == SqlTypDelete && b.softDelete() != nil {
+		typ = SqlTypUpdate
+	}
+	output, tail, _ = p.d.Returning(typ, names)
+	return
+}
+
+// returnInsertedIds asks for the generated ids of inserts LastInsertId can't describe, bulk inserts
+// and dialects without it. A multi row insert only gets them with a unique column to match them by.
+func (b *QueryBuilder) returnInsertedIds(d Dialect) {
+	if b.typ != SqlTypCreate || b.obj == nil || b.model == nil || b.model.AutoIncrement == nil || len(b.returning) > 0 {
+		return
+	}
+	if _, _, ok := d.Returning(b.typ, nil); !ok {
+		return
+	}
+	if b.multiRow() {
+		if key := b.returnKey(); key != nil {
+			b.returning = []string{b.model.AutoIncrement.Column, key.Column}
+		}
+		return
+	}
+	if reflect.ValueOf(b.obj).Elem().Kind() == reflect.Slice || !d.SupportsLastInsertId() {
+		b.returning = []string{b.model.AutoIncrement.Column}
+	}
+}
+
+// multiRow tells whether the statement inserts more than one bound element.
+func (b *QueryBuilder) multiRow() bool {
+	if b.typ != SqlTypCreate || b.obj == nil {
+		return false
+	}
+	vf := reflect.Indirect(reflect.ValueOf(b.obj).Elem())
+	return vf.Kind() == reflect.Slice && vf.Len() > 1
+}
+
+// returnKey is the unique column the rows a multi row insert returns are matched to the elements by.
+func (b *QueryBuilder) returnKey() *FieldInfo {
+	if b.model == nil {
+		return nil
+	}
+	keys := b.getKeys()
+	for _, f := range b.model.Fields {
+		if _, unique := f.Options["unique"]; !f.PrimaryKey && !unique {
+			continue
+		}
+		if some(keys, func(key string) bool { return key == f.Column }) {
+			return f
+		}
+	}
+	return nil
+}
+
+func (b *QueryBuilder) runReturning() error {
+	if b.multiRow() {
+		key := b.returnKey()
+		if key == nil {
+			return fmt.Errorf("%w: the rows of a multi row insert are matched by a unique column it writes, %s has none", ErrNoReturning, b.model.Type)
+		}
+		if !some(b.returning, func(column string) bool { return column == key.Column }) {
+			b.returning = append(b.returning, key.Column)
+		}
+	}
+	rows, err := b.query()
+	if err != nil {
+		return err
+	}
+	b.rowsAffected, err = b.scanReturned(rows)
+	return err
+}
+
+// checkFallback reports the statements the select fallback can't return the rows of: the rows are
+// scanned into a bound struct or slice, inserted rows are found by their autoincrement ids.
+func (b *QueryBuilder) checkFallback(d Dialect) error {
+	if b.obj == nil {
+		return fmt.Errorf("%w: %T returns rows only into a struct or slice given to Bind", ErrNoReturning, d)
+	}
+	if b.typ == SqlTypUpdate && (b.model == nil || len(b.model.PrimaryKeys) != 1) {
+		return fmt.Errorf("%w: %T returns updated rows only of models with a single primary key", ErrNoReturning, d)
+	}
+	if b.typ != SqlTypCreate {
+		return nil
+	}
+	if b.model == nil || b.model.AutoIncrement == nil || !d.SupportsLastInsertId() {
+		return fmt.Errorf("%w: %T returns inserted rows only of models with an autoincrement field", ErrNoReturning, d)
+	}
+	if vf := reflect.Indirect(reflect.ValueOf(b.obj).Elem()); vf.Kind() == reflect.Slice && vf.Len() > 1 {
+		return fmt.Errorf("%w: %T doesn't know the ids of a multi row insert", ErrNoReturning, d)
+	}
+	return nil
+}
+
+// fillInsertedIds writes LastInsertId into the bound struct or the only row of a bound slice. The ids
+// of a multi row insert are left alone: MySQL hands them out consecutively only with an
+// innodb_autoinc_lock_mode below 2 and an auto_increment_increment of 1, which are not the defaults.
+func (b *QueryBuilder) fillInsertedIds() error {
+	if b.obj == nil || b.model == nil || b.model.AutoIncrement == nil {
+		return nil
+	}
+	vf := reflect.Indirect(reflect.ValueOf(b.obj).Elem())
+	if vf.Kind() == reflect.Slice {
+		if vf.Len() != 1 {
+			return nil
+		}
+		vf = reflect.Indirect(vf.Index(0))
+	}
+	if vf.Kind() != reflect.Struct {
+		return nil
+	}
+	if val, ok := fieldOf(vf, b.model.AutoIncrement.Index, true); ok {
+		setInt(val, b.lastInsertedId)
+	}
+	if len(b.returning) == 0 {
+		return nil
+	}
+	_, err := b.selectReturning(b.model.AutoIncrement.Column, b.lastInsertedId)
+	return err
+}
+
+func (b *QueryBuilder) selectFrom() *QueryBuilder {
+	return &QueryBuilder{
+		typ:     SqlTypRead,
+		tables:  b.tables,
+		dialect: b.dialect,
+		ctx:     b.ctx,
+		exec:    b.exec,
+		model:   b.model,
+	}
+}
+
+// selectReturning reads the returning columns with a select, of the rows whose pk is one of the given
+// ids or, without a pk, of the rows the where clause matches.
+func (b *QueryBuilder) selectReturning(pk string, ids ...interface{}) (int64, error) {
+	r := b.selectFrom()
+	r.obj = b.obj
+	for _, column := range b.returning {
+		r.Columns(column)
+	}
+	if pk != "" {
+		if len(ids) == 0 {
+			return 0, nil
+		}
+		r.WhereIn(pk, ids).OrderBy(pk)
+		r.trashed = trashedWith
+	} else {
+		r.wheres = b.wheres
+		r.ops = b.ops
+		r.trashed = b.trashed
+	}
+	rows, err := r.query()
+	if err != nil {
+		return 0, err
+	}
+	return r.scanReturned(rows)
+}
+
+// selectKeys reads the primary keys of the rows the where clause matches, so that the rows can be
+// found after an update changed the columns it filters on.
+func (b *QueryBuilder) selectKeys() (keys []interface{}, err error) {
+	r := b.selectFrom()
+	r.wheres = b.wheres
+	r.ops = b.ops
+	r.trashed = b.trashed
+	r.Columns(b.primaryKey())
+	rows, err := r.query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key interface{}
+		if err = rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// scanReturned scans the rows into the bound struct or slice, the inserted id of the last row becomes
+// the LastInsertionId.
+func (b *QueryBuilder) scanReturned(rows *sql.Rows) (n int64, err error) {
+	defer rows.Close()
+	if b.obj == nil {
+		for rows.Next() {
+			n++
+		}
+		return n, rows.Err()
+	}
+	vf := reflect.Indirect(reflect.ValueOf(b.obj).Elem())
+	model := b.model
+	if model == nil {
+		typ := vf.Type()
+		if typ.Kind() == reflect.Slice {
+			typ = typ.Elem()
+		}
+		if model, err = getModel(typ); err != nil {
+			return
+		}
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return
+	}
+	plan := model.plan(columns)
+	key, elems := b.returnedElems(vf)
+	var ifc []interface{}
+	for rows.Next() {
+		var val reflect.Value
+		if key != nil {
+			val = reflect.New(model.Type).Elem()
+		} else if vf.Kind() == reflect.Slice && int(n) < vf.Len() {
+			val = element(vf.Index(int(n)))
+		} else if vf.Kind() == reflect.Struct && n == 0 {
+			val = vf
+		}
+		n++
+		if !val.IsValid() {
+			continue
+		}
+		ifc = plan.targets(val, ifc)
+		if err = rows.Scan(ifc...); err != nil {
+			return
+		}
+		if key != nil {
+			row := val
+			if k, ok := fieldOf(row, key.Index, false); ok {
+				val = elems[keyOf(k)]
+			}
+			if !val.IsValid() {
+				continue
+			}
+			for _, index := range plan.fields {
+				if index != nil {
+					from, _ := fieldOf(row, index, false)
+					if to, ok := fieldOf(val, index, true); ok {
+						to.Set(from)
+					}
+				}
+			}
+		}
+		if b.typ == SqlTypCreate && model.AutoIncrement != nil {
+			if id, ok := fieldOf(val, model.AutoIncrement.Index, false); ok && id.CanInt() {
+				b.lastInsertedId = id.Int()
+			}
+		}
+	}
+	return n, rows.Err()
+}
+
+// returnedElems indexes the elements of a multi row insert by the column its returned rows are matched by.
+func (b *QueryBuilder) returnedElems(vf reflect.Value) (*FieldInfo, map[interface{}]reflect.Value) {
+	if !b.multiRow() {
+		return nil, nil
+	}
+	key := b.returnKey()
+	if key == nil {
+		return nil, nil
+	}
+	elems := make(map[interface{}]reflect.Value, vf.Len())
+	for i := 0; i < vf.Len(); i++ {
+		elem := element(vf.Index(i))
+		if k, ok := fieldOf(elem, key.Index, false); ok {
+			elems[keyOf(k)] = elem
+		}
+	}
+	return key, elems
+}
+
+// element is the struct of a slice element, allocating nil pointers.
+func element(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	return v
+}
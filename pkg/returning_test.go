@@ -0,0 +1,99 @@
+package gql
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestReturning(t *testing.T) {
+	checkSQL(t, func() Builder { return Create("users").Set("name", "a").Returning("id", "name") }, golden{
+		"mysql":     "INSERT INTO `users`(`name`) VALUES(?)",
+		"postgres":  `INSERT INTO "users"("name") VALUES($1) RETURNING "id", "name"`,
+		"sqlite":    `INSERT INTO "users"("name") VALUES(?) RETURNING "id", "name"`,
+		"sqlserver": "INSERT INTO [users]([name]) OUTPUT INSERTED.[id], INSERTED.[name] VALUES(@p1)",
+	}, "a")
+	checkSQL(t, func() Builder { return Update("users").Set("name", "a").Where("id", 1).Returning("id") }, golden{
+		"postgres":  `UPDATE "users" SET "name"=$1 WHERE "id" = $2 RETURNING "id"`,
+		"sqlserver": "UPDATE [users] SET [name]=@p1 OUTPUT INSERTED.[id] WHERE [id] = @p2",
+	}, "a", 1)
+	checkSQL(t, func() Builder { return Delete("users").Where("id", 1).Returning("id") }, golden{
+		"postgres":  `DELETE FROM "users" WHERE "id" = $1 RETURNING "id"`,
+		"sqlserver": "DELETE FROM [users] OUTPUT DELETED.[id] WHERE [id] = @p1",
+	}, 1)
+}
+
+func TestReturningFallback(t *testing.T) {
+	type plain struct {
+		Name string `gql:"name"`
+	}
+	type keyed struct {
+		ID   int64  `gql:"id"`
+		Name string `gql:"name"`
+	}
+	cases := map[string]struct {
+		b  Builder
+		ok bool
+	}{
+		"unbound":        {Update("users").Set("name", "a").Where("id", 1).Returning("id"), false},
+		"no autoinc":     {Create("").Bind(&plain{Name: "a"}).Returning("name"), false},
+		"multi row":      {Create("").Bind(&[]keyed{{Name: "a"}, {Name: "b"}}).Returning("name"), false},
+		"no primary key": {Update("").Bind(&plain{Name: "a"}).Where("name", "b").Returning("name"), false},
+		"insert":         {Create("").Bind(&keyed{Name: "a"}).Returning("name"), true},
+		"update":         {Update("").Bind(&keyed{Name: "a"}).Where("id", 1).Returning("name"), true},
+	}
+	for name, c := range cases {
+		err := c.b.(*QueryBuilder).checkFallback(MySQL)
+		if c.ok != (err == nil) || err != nil && !errors.Is(err, ErrNoReturning) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestReturningMatch(t *testing.T) {
+	type member struct {
+		ID    int64  `gql:"id"`
+		Email string `gql:"email,unique"`
+		Name  string `gql:"name"`
+	}
+	members := []member{{Email: "a@x", Name: "a"}, {Email: "b@x", Name: "b"}, {Email: "c@x", Name: "c"}}
+	db, f := newFakeDB(t, fakeAnswer{columns: []string{"id", "email"}, rows: [][]driver.Value{{9, "c@x"}, {7, "a@x"}}})
+	var id int64
+	err := Create("").WithDialect(PostgreSQL).Bind(&members).OnConflict("email").DoNothing().Use(db).Run().LastInsertionId(&id).GetError()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if members[0].ID != 7 || members[1].ID != 0 || members[2].ID != 9 || id != 7 {
+		t.Errorf("%v, last id %d", members, id)
+	}
+	checkStatements(t, f, `INSERT INTO "members"("email", "name") VALUES($1, $2), ($3, $4), ($5, $6) ON CONFLICT ("email") DO NOTHING RETURNING "id", "email"`)
+
+	db, _ = newFakeDB(t, fakeAnswer{columns: []string{"id", "email"}, rows: [][]driver.Value{{3, "b@x"}, {2, "a@x"}, {1, "c@x"}}})
+	if err = Create("").WithDialect(SQLServer).Bind(&members).Use(db).Run().GetError(); err != nil {
+		t.Fatal(err)
+	}
+	if members[0].ID != 2 || members[1].ID != 3 || members[2].ID != 1 {
+		t.Errorf("%v", members)
+	}
+
+	db, f = newFakeDB(t)
+	err = Create("").WithDialect(PostgreSQL).Bind(&members).Returning("name").Use(db).Run().GetError()
+	checkStatements(t, f, `INSERT INTO "members"("email", "name") VALUES($1, $2), ($3, $4), ($5, $6) RETURNING "name", "email"`)
+	if err != nil {
+		t.Error(err)
+	}
+
+	type note struct {
+		ID   int64  `gql:"id"`
+		Text string `gql:"text"`
+	}
+	notes := []note{{Text: "a"}, {Text: "b"}}
+	db, f = newFakeDB(t)
+	if err = Create("").WithDialect(PostgreSQL).Bind(&notes).Returning("id").Use(db).Run().GetError(); !errors.Is(err, ErrNoReturning) {
+		t.Errorf("returned unmatched rows: %v", err)
+	}
+	if err = Create("").WithDialect(PostgreSQL).Bind(&notes).Use(db).Run().GetError(); err != nil {
+		t.Fatal(err)
+	}
+	checkStatements(t, f, `INSERT INTO "notes"("text") VALUES($1), ($2)`)
+}
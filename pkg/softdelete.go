@@ -0,0 +1,52 @@
+package gql
+
+import "strings"
+
+const (
+	trashedHidden = iota
+	trashedWith
+	trashedOnly
+)
+
+// WithTrashed includes the soft deleted rows of a model with a softdelete field.
+func (b *QueryBuilder) WithTrashed() Builder {
+	b.trashed = trashedWith
+	return b
+}
+
+// OnlyTrashed matches the soft deleted rows only.
+func (b *QueryBuilder) OnlyTrashed() Builder {
+	b.trashed = trashedOnly
+	return b
+}
+
+// ForceDelete removes the rows for real instead of stamping the softdelete field.
+func (b *QueryBuilder) ForceDelete() Builder {
+	b.force = true
+	b.trashed = trashedWith
+	return b
+}
+
+func (b *QueryBuilder) softDelete() *FieldInfo {
+	if b.model == nil || b.force {
+		return nil
+	}
+	return b.model.SoftDelete
+}
+
+func (b *QueryBuilder) trashedClause(p *params) string {
+	f := b.softDelete()
+	if f == nil || b.trashed == trashedWith || b.typ == SqlTypCreate || b.typ == SqlTypCustom {
+		return ""
+	}
+	column := p.name(f.Column)
+	// qualify by the alias of the first table, "users u" or "users AS u", so joins stay unambiguous
+	if len(b.tables) > 0 && b.tables[0] != "" {
+		parts := strings.Fields(b.tables[0])
+		column = p.name(parts[len(parts)-1]) + "." + column
+	}
+	if b.trashed == trashedOnly {
+		return column + " IS NOT NULL"
+	}
+	return column + " IS NULL"
+}
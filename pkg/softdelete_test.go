@@ -0,0 +1,44 @@
+package gql
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+type trashUser struct {
+	ID        int64    `gql:"id"`
+	Name      string   `gql:"name"`
+	DeletedAt NullTime `gql:"deleted_at,softdelete"`
+}
+
+func (trashUser) TableName() string { return "users" }
+
+func TestSoftDelete(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	SetClock(func() time.Time { return now })
+	defer SetClock(nil)
+
+	checkSQL(t, func() Builder { return Read("").Model(&trashUser{}).Where("name", "a").Or().Where("name", "b") }, golden{
+		"mysql":     "SELECT * FROM `users` WHERE (`name` = ? OR `name` = ?) AND `users`.`deleted_at` IS NULL",
+		"postgres":  `SELECT * FROM "users" WHERE ("name" = $1 OR "name" = $2) AND "users"."deleted_at" IS NULL`,
+		"sqlserver": "SELECT * FROM [users] WHERE ([name] = @p1 OR [name] = @p2) AND [users].[deleted_at] IS NULL",
+	}, "a", "b")
+	checkSQL(t, func() Builder { return Read("users u").Model(&trashUser{}).Where("u.id", 1) }, golden{
+		"postgres": `SELECT * FROM users u WHERE ("u"."id" = $1) AND "u"."deleted_at" IS NULL`,
+	}, 1)
+	checkSQL(t, func() Builder { return Read("").Model(&trashUser{}).OnlyTrashed() }, golden{
+		"postgres": `SELECT * FROM "users" WHERE "users"."deleted_at" IS NOT NULL`,
+	})
+	checkSQL(t, func() Builder { return Read("").Model(&trashUser{}).WithTrashed() }, golden{
+		"postgres": `SELECT * FROM "users"`,
+	})
+	checkSQL(t, func() Builder { return Delete("").Model(&trashUser{}).Where("id", 1) }, golden{
+		"mysql":     "UPDATE `users` SET `deleted_at`=? WHERE (`id` = ?) AND `users`.`deleted_at` IS NULL",
+		"postgres":  `UPDATE "users" SET "deleted_at"=$1 WHERE ("id" = $2) AND "users"."deleted_at" IS NULL`,
+		"sqlserver": "UPDATE [users] SET [deleted_at]=@p1 WHERE ([id] = @p2) AND [users].[deleted_at] IS NULL",
+	}, NullTime{sql.NullTime{Time: now, Valid: true}}, 1)
+	checkSQL(t, func() Builder { return Delete("").Model(&trashUser{}).Where("id", 1).ForceDelete() }, golden{
+		"postgres": `DELETE FROM "users" WHERE "id" = $1`,
+	}, 1)
+}
@@ -12,6 +12,7 @@ type SqlTyp uint8
 
 type SqlReserved struct {
 	content string
+	now     bool
 }
 
 const (
@@ -22,8 +23,15 @@ const (
 	SqlTypCustom = SqlTyp(4)
 )
 
+func (r SqlReserved) render(d Dialect) string {
+	if r.now {
+		return d.Now()
+	}
+	return r.content
+}
+
 func Now() SqlReserved {
-	return SqlReserved{content: "NOW()"}
+	return SqlReserved{content: "NOW()", now: true}
 }
 func Sql(sql string) SqlReserved {
 	return SqlReserved{content: sql}
@@ -61,10 +69,11 @@ func Query(fn func(builder Builder), alias string) string {
 	return "(" + b.Query() + ") " + alias
 }
 
-func Custom(query string) Builder {
+func Custom(query string, args ...interface{}) Builder {
 	q := QueryBuilder{}
 	q.typ = SqlTypCustom
 	q.customQuery = query
+	q.customArgs = args
 	return &q
 }
 
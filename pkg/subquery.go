@@ -0,0 +1,71 @@
+package gql
+
+import "fmt"
+
+// SqlSubquery is a select nested in another statement, usable as a value of the Where methods and as a
+// column. Its arguments are bound in place among those of the outer statement.
+type SqlSubquery struct {
+	builder *QueryBuilder
+	alias   string
+}
+
+// Subquery builds a nested select, the alias names it when used as a column.
+func Subquery(fn func(b Builder), alias ...string) SqlSubquery {
+	s := SqlSubquery{builder: &QueryBuilder{typ: SqlTypRead}}
+	fn(s.builder)
+	if len(alias) > 0 {
+		s.alias = alias[0]
+	}
+	return s
+}
+
+func (s SqlSubquery) render(p *params) string {
+	if s.builder.err != nil && p.err == nil {
+		p.err = s.builder.err
+	}
+	out := "(" + s.builder.render(p) + ")"
+	if s.alias != "" {
+		out += " " + p.name(s.alias)
+	}
+	return out
+}
+
+func (b *QueryBuilder) WhereNotInQuery(field string, fn func(b Builder)) Builder {
+	sub := Subquery(fn)
+	name := b.extractName(field)
+	return b.where(func(p *params) string {
+		return p.name(name) + " NOT IN " + sub.render(p)
+	})
+}
+
+func (b *QueryBuilder) WhereExists(fn func(b Builder)) Builder {
+	sub := Subquery(fn)
+	return b.where(func(p *params) string {
+		return "EXISTS " + sub.render(p)
+	})
+}
+
+func (b *QueryBuilder) WhereNotExists(fn func(b Builder)) Builder {
+	sub := Subquery(fn)
+	return b.where(func(p *params) string {
+		return "NOT EXISTS " + sub.render(p)
+	})
+}
+
+// FromSub selects from the rows of another select under the given alias.
+func (b *QueryBuilder) FromSub(builder Builder, alias string) Builder {
+	q, ok := builder.(*QueryBuilder)
+	if !ok {
+		b.err = fmt.Errorf("gql: %T can't be used as a subquery", builder)
+		return b
+	}
+	if b.subqueries == nil {
+		b.subqueries = make(map[string]SqlSubquery)
+	}
+	b.subqueries[alias] = SqlSubquery{builder: q, alias: alias}
+	if len(b.tables) == 1 && b.tables[0] == "" {
+		b.tables = nil
+	}
+	b.tables = append(b.tables, alias)
+	return b
+}
@@ -0,0 +1,37 @@
+package gql
+
+import "testing"
+
+func TestSubquery(t *testing.T) {
+	checkSQL(t, func() Builder {
+		return Read("orders").Where("status", "paid").WhereGT("total", Subquery(func(b Builder) {
+			b.Table("orders").Columns(Sql("AVG(total)")).Where("status", "paid")
+		})).WhereExists(func(b Builder) {
+			b.Table("items").Columns(Sql("1")).Where("qty", 3)
+		}).WhereNotInQuery("user_id", func(b Builder) {
+			b.Table("bans").Columns("user_id").WhereGT("level", 2)
+		})
+	}, golden{
+		"mysql":     "SELECT * FROM `orders` WHERE `status` = ? AND `total` > (SELECT AVG(total) FROM `orders` WHERE `status` = ?) AND EXISTS (SELECT 1 FROM `items` WHERE `qty` = ?) AND `user_id` NOT IN (SELECT `user_id` FROM `bans` WHERE `level` > ?)",
+		"postgres":  `SELECT * FROM "orders" WHERE "status" = $1 AND "total" > (SELECT AVG(total) FROM "orders" WHERE "status" = $2) AND EXISTS (SELECT 1 FROM "items" WHERE "qty" = $3) AND "user_id" NOT IN (SELECT "user_id" FROM "bans" WHERE "level" > $4)`,
+		"sqlserver": "SELECT * FROM [orders] WHERE [status] = @p1 AND [total] > (SELECT AVG(total) FROM [orders] WHERE [status] = @p2) AND EXISTS (SELECT 1 FROM [items] WHERE [qty] = @p3) AND [user_id] NOT IN (SELECT [user_id] FROM [bans] WHERE [level] > @p4)",
+	}, "paid", "paid", 3, 2)
+	checkSQL(t, func() Builder {
+		return Read("users").WhereNotExists(func(b Builder) { b.Table("bans").Columns(Sql("1")).Where("reason", "spam") })
+	}, golden{
+		"postgres": `SELECT * FROM "users" WHERE NOT EXISTS (SELECT 1 FROM "bans" WHERE "reason" = $1)`,
+	}, "spam")
+}
+
+func TestSubqueryColumnsAndFrom(t *testing.T) {
+	count := Subquery(func(b Builder) { b.Table("orders").Columns(Sql("COUNT(*)")).Where("user_id", 7) }, "n")
+	for _, column := range []interface{}{count, &count} {
+		checkSQL(t, func() Builder {
+			return Read("").FromSub(Read("users").WhereGT("age", 5), "u").Columns("name", column).Where("u.name", "x")
+		}, golden{
+			"mysql":     "SELECT `name`, (SELECT COUNT(*) FROM `orders` WHERE `user_id` = ?) `n` FROM (SELECT * FROM `users` WHERE `age` > ?) `u` WHERE `u`.`name` = ?",
+			"postgres":  `SELECT "name", (SELECT COUNT(*) FROM "orders" WHERE "user_id" = $1) "n" FROM (SELECT * FROM "users" WHERE "age" > $2) "u" WHERE "u"."name" = $3`,
+			"sqlserver": "SELECT [name], (SELECT COUNT(*) FROM [orders] WHERE [user_id] = @p1) [n] FROM (SELECT * FROM [users] WHERE [age] > @p2) [u] WHERE [u].[name] = @p3",
+		}, 7, 5, "x")
+	}
+}
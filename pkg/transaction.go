@@ -0,0 +1,89 @@
+package gql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type Tx struct {
+	*sql.Tx
+	dialect Dialect
+	depth   int
+}
+
+type beginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+func Transaction(e Executor, fn func(tx Executor) error) error {
+	return TransactionContext(context.Background(), e, nil, fn)
+}
+
+// TransactionContext runs fn inside a transaction started on e, committing when fn returns nil and
+// rolling back on error or panic. When e is already a transaction a savepoint is used instead, so
+// opts only apply to the outermost call.
+func TransactionContext(ctx context.Context, e Executor, opts *sql.TxOptions, fn func(tx Executor) error) error {
+	return TransactionDialect(ctx, e, nil, opts, fn)
+}
+
+// TransactionDialect is TransactionContext for a database of the dialect d, which writes the savepoints
+// of nested calls. Nested calls without a dialect keep the one of the outer transaction.
+func TransactionDialect(ctx context.Context, e Executor, d Dialect, opts *sql.TxOptions, fn func(tx Executor) error) (err error) {
+	switch t := e.(type) {
+	case *Tx:
+		if d != nil {
+			t = &Tx{Tx: t.Tx, dialect: d, depth: t.depth}
+		}
+		return t.savepoint(ctx, fn)
+	case *sql.Tx:
+		if d == nil {
+			d = defaultDialect
+		}
+		return (&Tx{Tx: t, dialect: d}).savepoint(ctx, fn)
+	}
+	if d == nil {
+		d = defaultDialect
+	}
+	db, ok := e.(beginner)
+	if !ok {
+		return fmt.Errorf("gql: %T cannot begin a transaction", e)
+	}
+	sqlTx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return
+	}
+	tx := &Tx{Tx: sqlTx, dialect: d}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+	err = fn(tx)
+	return
+}
+
+func (t *Tx) savepoint(ctx context.Context, fn func(tx Executor) error) (err error) {
+	nested := &Tx{Tx: t.Tx, dialect: t.dialect, depth: t.depth + 1}
+	name := fmt.Sprintf("gql_sp_%d", nested.depth)
+	if _, err = t.ExecContext(ctx, t.dialect.Savepoint(name)); err != nil {
+		return
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			t.ExecContext(ctx, t.dialect.RollbackTo(name))
+			panic(p)
+		} else if err != nil {
+			t.ExecContext(ctx, t.dialect.RollbackTo(name))
+		} else if release := t.dialect.Release(name); release != "" {
+			_, err = t.ExecContext(ctx, release)
+		}
+	}()
+	err = fn(nested)
+	return
+}
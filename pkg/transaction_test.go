@@ -0,0 +1,81 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSavepoints(t *testing.T) {
+	want := map[string][3]string{
+		"mysql":     {"SAVEPOINT gql_sp_1", "ROLLBACK TO SAVEPOINT gql_sp_1", "RELEASE SAVEPOINT gql_sp_1"},
+		"postgres":  {"SAVEPOINT gql_sp_1", "ROLLBACK TO SAVEPOINT gql_sp_1", "RELEASE SAVEPOINT gql_sp_1"},
+		"sqlite":    {"SAVEPOINT gql_sp_1", "ROLLBACK TO SAVEPOINT gql_sp_1", "RELEASE SAVEPOINT gql_sp_1"},
+		"sqlserver": {"SAVE TRANSACTION gql_sp_1", "ROLLBACK TRANSACTION gql_sp_1", ""},
+	}
+	for _, td := range testDialects {
+		got := [3]string{td.d.Savepoint("gql_sp_1"), td.d.RollbackTo("gql_sp_1"), td.d.Release("gql_sp_1")}
+		if got != want[td.name] {
+			t.Errorf("%s: %q", td.name, got)
+		}
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	ctx := context.Background()
+	fail := errors.New("fail")
+	insert := func(query string) func(tx Executor) error {
+		return func(tx Executor) error {
+			_, err := tx.ExecContext(ctx, query)
+			return err
+		}
+	}
+
+	db, f := newFakeDB(t)
+	if err := TransactionDialect(ctx, db, PostgreSQL, nil, insert("INSERT 1")); err != nil {
+		t.Fatal(err)
+	}
+	checkStatements(t, f, "BEGIN", "INSERT 1", "COMMIT")
+
+	db, f = newFakeDB(t)
+	err := TransactionDialect(ctx, db, PostgreSQL, nil, func(tx Executor) error {
+		insert("INSERT 1")(tx)
+		return fail
+	})
+	if err != fail {
+		t.Errorf("returned %v", err)
+	}
+	checkStatements(t, f, "BEGIN", "INSERT 1", "ROLLBACK")
+
+	db, f = newFakeDB(t)
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("recovered %v", p)
+			}
+		}()
+		TransactionDialect(ctx, db, PostgreSQL, nil, func(tx Executor) error { panic("boom") })
+	}()
+	checkStatements(t, f, "BEGIN", "ROLLBACK")
+
+	db, f = newFakeDB(t)
+	err = TransactionDialect(ctx, db, PostgreSQL, nil, func(tx Executor) error {
+		if err := TransactionContext(ctx, tx, nil, insert("INSERT 1")); err != nil {
+			return err
+		}
+		if err := TransactionContext(ctx, tx, nil, func(tx Executor) error {
+			insert("INSERT 2")(tx)
+			return fail
+		}); err != fail {
+			t.Errorf("nested returned %v", err)
+		}
+		return insert("INSERT 3")(tx)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStatements(t, f, "BEGIN",
+		"SAVEPOINT gql_sp_1", "INSERT 1", "RELEASE SAVEPOINT gql_sp_1",
+		"SAVEPOINT gql_sp_1", "INSERT 2", "ROLLBACK TO SAVEPOINT gql_sp_1",
+		"INSERT 3", "COMMIT")
+}
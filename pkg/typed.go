@@ -0,0 +1,192 @@
+package gql
+
+import "context"
+
+// Typed is a read builder bound to the model T, its terminal methods return values instead of
+// filling out-parameters.
+type Typed[T any] struct {
+	b Builder
+}
+
+func From[T any](e Executor) *Typed[T] {
+	var model T
+	return &Typed[T]{b: Read("").Model(&model).Use(e)}
+}
+
+func (t *Typed[T]) Builder() Builder {
+	return t.b
+}
+
+// Apply runs fn against the underlying builder for anything Typed doesn't wrap.
+func (t *Typed[T]) Apply(fn func(b Builder)) *Typed[T] {
+	fn(t.b)
+	return t
+}
+
+// Table reads from the given table instead of the one of the model.
+func (t *Typed[T]) Table(table string) *Typed[T] {
+	if b, ok := t.b.(*QueryBuilder); ok {
+		b.tables = []string{table}
+		return t
+	}
+	t.b.Table(table)
+	return t
+}
+
+func (t *Typed[T]) Columns(columns ...interface{}) *Typed[T] {
+	t.b.Columns(columns...)
+	return t
+}
+
+func (t *Typed[T]) Where(field string, value interface{}) *Typed[T] {
+	t.b.Where(field, value)
+	return t
+}
+
+func (t *Typed[T]) WhereNot(field string, value interface{}) *Typed[T] {
+	t.b.WhereNot(field, value)
+	return t
+}
+
+func (t *Typed[T]) WhereLike(field string, value interface{}) *Typed[T] {
+	t.b.WhereLike(field, value)
+	return t
+}
+
+func (t *Typed[T]) WhereGT(field string, value interface{}) *Typed[T] {
+	t.b.WhereGT(field, value)
+	return t
+}
+
+func (t *Typed[T]) WhereGTE(field string, value interface{}) *Typed[T] {
+	t.b.WhereGTE(field, value)
+	return t
+}
+
+func (t *Typed[T]) WhereLT(field string, value interface{}) *Typed[T] {
+	t.b.WhereLT(field, value)
+	return t
+}
+
+func (t *Typed[T]) WhereLTE(field string, value interface{}) *Typed[T] {
+	t.b.WhereLTE(field, value)
+	return t
+}
+
+func (t *Typed[T]) WhereBetween(field string, value1 interface{}, value2 interface{}) *Typed[T] {
+	t.b.WhereBetween(field, value1, value2)
+	return t
+}
+
+func (t *Typed[T]) WhereIn(field string, value []interface{}) *Typed[T] {
+	t.b.WhereIn(field, value)
+	return t
+}
+
+func (t *Typed[T]) WhereNull(field string) *Typed[T] {
+	t.b.WhereNull(field)
+	return t
+}
+
+func (t *Typed[T]) WhereNotNull(field string) *Typed[T] {
+	t.b.WhereNotNull(field)
+	return t
+}
+
+func (t *Typed[T]) WhereGroup(fn func(b Builder)) *Typed[T] {
+	t.b.WhereGroup(fn)
+	return t
+}
+
+func (t *Typed[T]) Find(value interface{}) *Typed[T] {
+	t.b.Find(value)
+	return t
+}
+
+func (t *Typed[T]) Or() *Typed[T] {
+	t.b.Or()
+	return t
+}
+
+func (t *Typed[T]) And() *Typed[T] {
+	t.b.And()
+	return t
+}
+
+func (t *Typed[T]) OrderBy(clause ...string) *Typed[T] {
+	t.b.OrderBy(clause...)
+	return t
+}
+
+func (t *Typed[T]) Top(top int64) *Typed[T] {
+	t.b.Top(top)
+	return t
+}
+
+func (t *Typed[T]) Offset(offset int64) *Typed[T] {
+	t.b.Offset(offset)
+	return t
+}
+
+func (t *Typed[T]) Paginate(page int64, take int64) *Typed[T] {
+	t.b.Paginate(page, take)
+	return t
+}
+
+func (t *Typed[T]) Preload(relations ...string) *Typed[T] {
+	t.b.Preload(relations...)
+	return t
+}
+
+func (t *Typed[T]) WithTrashed() *Typed[T] {
+	t.b.WithTrashed()
+	return t
+}
+
+func (t *Typed[T]) OnlyTrashed() *Typed[T] {
+	t.b.OnlyTrashed()
+	return t
+}
+
+func (t *Typed[T]) All(ctx context.Context) (out []T, err error) {
+	err = t.b.WithContext(ctx).Scan(&out).GetError()
+	return
+}
+
+func (t *Typed[T]) One(ctx context.Context) (out T, err error) {
+	err = t.b.WithContext(ctx).First(&out).GetError()
+	return
+}
+
+func (t *Typed[T]) Count(ctx context.Context) (count int64, err error) {
+	err = t.b.WithContext(ctx).Count(&count).GetError()
+	return
+}
+
+func (t *Typed[T]) Rows(ctx context.Context) (*Iterator[T], error) {
+	return Rows[T](ctx, t.b)
+}
+
+func (t *Typed[T]) Each(ctx context.Context, fn func(T) error) error {
+	return Each[T](ctx, t.b, fn)
+}
+
+func Insert[T any](e Executor, value *T) error {
+	return InsertContext(context.Background(), e, value)
+}
+
+// InsertContext inserts value, its hooks get ctx.
+func InsertContext[T any](ctx context.Context, e Executor, value *T) error {
+	return Create("").Bind(value).Use(e).WithContext(ctx).Run().GetError()
+}
+
+func InsertAll[T any](e Executor, values []T) error {
+	return InsertAllContext(context.Background(), e, values)
+}
+
+func InsertAllContext[T any](ctx context.Context, e Executor, values []T) error {
+	if len(values) == 0 {
+		return nil
+	}
+	return Create("").Bind(&values).Use(e).WithContext(ctx).Run().GetError()
+}
@@ -0,0 +1,66 @@
+package gql
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+)
+
+type typedUser struct {
+	ID   int64  `gql:"id"`
+	Name string `gql:"name"`
+}
+
+func TestTypedTable(t *testing.T) {
+	q := From[typedUser](nil).Builder().WithDialect(PostgreSQL).Query()
+	if q != `SELECT * FROM "typed_users"` {
+		t.Error(q)
+	}
+	q = From[typedUser](nil).Table("app_users").Where("name", "a").Builder().WithDialect(PostgreSQL).Query()
+	if q != `SELECT * FROM "app_users" WHERE "name" = 'a'` {
+		t.Error(q)
+	}
+}
+
+func TestTyped(t *testing.T) {
+	ctx := context.Background()
+	rows := fakeAnswer{columns: []string{"id", "name"}, rows: [][]driver.Value{{1, "a"}, {2, "b"}}}
+	db, f := newFakeDB(t, rows)
+	users, err := From[typedUser](db).Where("name", "a").OrderBy("-id").All(ctx)
+	if err != nil || len(users) != 2 || users[1] != (typedUser{2, "b"}) {
+		t.Errorf("%v, %v", users, err)
+	}
+	checkStatements(t, f, "SELECT * FROM `typed_users` WHERE `name` = ? ORDER BY `id` DESC")
+
+	db, f = newFakeDB(t, rows)
+	user, err := From[typedUser](db).Find(1).One(ctx)
+	if err != nil || user != (typedUser{1, "a"}) {
+		t.Errorf("%v, %v", user, err)
+	}
+	checkStatements(t, f, "SELECT * FROM `typed_users` WHERE `id` = ? LIMIT 1")
+
+	db, _ = newFakeDB(t)
+	if _, err = From[typedUser](db).Find(3).One(ctx); err != ErrNoRows {
+		t.Errorf("missing row: %v", err)
+	}
+
+	db, f = newFakeDB(t, fakeAnswer{columns: []string{"len"}, rows: [][]driver.Value{{7}}})
+	count, err := From[typedUser](db).WhereGT("id", 1).Count(ctx)
+	if err != nil || count != 7 {
+		t.Errorf("%d, %v", count, err)
+	}
+	checkStatements(t, f, "SELECT COUNT(*) len FROM (SELECT * FROM `typed_users` WHERE `id` > ?) `a`")
+
+	db, f = newFakeDB(t, fakeAnswer{columns: []string{"len"}, rows: [][]driver.Value{{7}}}, fakeAnswer{columns: []string{"len"}, rows: [][]driver.Value{{2}}})
+	ordered := From[typedUser](db).Apply(func(b Builder) { b.WithDialect(SQLServer) }).OrderBy("id")
+	if count, err = ordered.Count(ctx); err != nil || count != 7 {
+		t.Errorf("%d, %v", count, err)
+	}
+	if count, err = ordered.Top(2).Count(ctx); err != nil || count != 2 {
+		t.Errorf("%d, %v", count, err)
+	}
+	checkStatements(t, f,
+		"SELECT COUNT(*) len FROM (SELECT * FROM [typed_users]) [a]",
+		"SELECT COUNT(*) len FROM (SELECT TOP 2 * FROM [typed_users] ORDER BY [id] ASC) [a]",
+	)
+}
@@ -0,0 +1,88 @@
+package gql
+
+type upsert struct {
+	conflict []string
+	update   []string
+	nothing  bool
+}
+
+// OnConflict turns an insert into an upsert on the given unique columns, by default every inserted
+// column except the conflicting ones and the autocreatetime, version and softdelete fields of the
+// model is overwritten. An update of a model with a version field increments it, unless DoUpdate
+// names the field.
+func (b *QueryBuilder) OnConflict(columns ...string) Builder {
+	b.upsert = &upsert{}
+	for _, column := range columns {
+		b.upsert.conflict = append(b.upsert.conflict, b.extractName(column))
+	}
+	return b
+}
+
+func (b *QueryBuilder) DoUpdate(columns ...string) Builder {
+	if b.upsert == nil {
+		b.upsert = &upsert{}
+	}
+	b.upsert.nothing = false
+	b.upsert.update = nil
+	for _, column := range columns {
+		b.upsert.update = append(b.upsert.update, b.extractName(column))
+	}
+	return b
+}
+
+func (b *QueryBuilder) DoNothing() Builder {
+	if b.upsert == nil {
+		b.upsert = &upsert{}
+	}
+	b.upsert.nothing = true
+	return b
+}
+
+func (u *upsert) render(p *params, table string, keys []string, m *ModelInfo) string {
+	conflict := make([]string, len(u.conflict))
+	for i, column := range u.conflict {
+		conflict[i] = p.name(column)
+	}
+	columns := make([]string, len(keys))
+	for i, key := range keys {
+		columns[i] = p.name(key)
+	}
+	var update []string
+	if !u.nothing {
+		names := u.update
+		if len(names) == 0 {
+			for _, key := range keys {
+				if !some(u.conflict, func(column string) bool {
+					return column == key
+				}) && !preserved(m, key) {
+					names = append(names, key)
+				}
+			}
+		}
+		for _, name := range names {
+			update = append(update, p.name(name))
+		}
+	}
+	var assign []string
+	if len(update) > 0 && m != nil && m.Version != nil && !some(u.update, func(column string) bool {
+		return column == m.Version.Column
+	}) {
+		// the existing row is qualified by its table, PostgreSQL finds a bare column ambiguous
+		version := p.name(m.Version.Column)
+		assign = append(assign, version+"="+p.name(table)+"."+version+" + 1")
+	}
+	out, err := p.d.Upsert(conflict, update, assign, columns)
+	if err != nil {
+		p.err = err
+	}
+	return out
+}
+
+// preserved reports the columns the default update of an upsert leaves as they are in the existing row.
+func preserved(m *ModelInfo, column string) bool {
+	if m == nil {
+		return false
+	}
+	f := m.Column(column)
+	return f != nil && (f.AutoCreateTime || f.Version || f.SoftDelete)
+}
@@ -0,0 +1,50 @@
+package gql
+
+import (
+	"testing"
+	"time"
+)
+
+type upsertUser struct {
+	ID        int64     `gql:"id"`
+	Email     string    `gql:"email"`
+	Name      string    `gql:"name"`
+	CreatedAt time.Time `gql:"created_at,autocreatetime"`
+	Version   int64     `gql:"version,version"`
+	DeletedAt NullTime  `gql:"deleted_at,softdelete"`
+}
+
+func (upsertUser) TableName() string { return "users" }
+
+func TestUpsert(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	SetClock(func() time.Time { return now })
+	defer SetClock(nil)
+
+	checkSQL(t, func() Builder { return Create("").Bind(&upsertUser{Email: "e", Name: "n"}).OnConflict("email") }, golden{
+		"mysql":    "INSERT INTO `users`(`email`, `name`, `created_at`, `version`, `deleted_at`) VALUES(?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE `name`=VALUES(`name`), `version`=`users`.`version` + 1",
+		"postgres": `INSERT INTO "users"("email", "name", "created_at", "version", "deleted_at") VALUES($1, $2, $3, $4, $5) ON CONFLICT ("email") DO UPDATE SET "name"=EXCLUDED."name", "version"="users"."version" + 1`,
+		"sqlite":   `INSERT INTO "users"("email", "name", "created_at", "version", "deleted_at") VALUES(?, ?, ?, ?, ?) ON CONFLICT ("email") DO UPDATE SET "name"=EXCLUDED."name", "version"="users"."version" + 1`,
+	}, "e", "n", now, int64(0), NullTime{})
+	checkSQL(t, func() Builder {
+		return Create("users").Set("email", "e").Set("name", "n").OnConflict("email").DoUpdate("name", "email")
+	}, golden{
+		"postgres": `INSERT INTO "users"("email", "name") VALUES($1, $2) ON CONFLICT ("email") DO UPDATE SET "name"=EXCLUDED."name", "email"=EXCLUDED."email"`,
+	}, "e", "n")
+	checkSQL(t, func() Builder { return Create("users").Set("email", "e").OnConflict("email").DoNothing() }, golden{
+		"mysql":    "INSERT INTO `users`(`email`) VALUES(?) ON DUPLICATE KEY UPDATE `email`=`email`",
+		"postgres": `INSERT INTO "users"("email") VALUES($1) ON CONFLICT ("email") DO NOTHING`,
+		"sqlite":   `INSERT INTO "users"("email") VALUES(?) ON CONFLICT ("email") DO NOTHING`,
+	}, "e")
+
+	checkSQL(t, func() Builder {
+		return Create("").Bind(&upsertUser{Email: "e", Name: "n", Version: 3}).OnConflict("email").DoUpdate("name", "version")
+	}, golden{
+		"postgres": `INSERT INTO "users"("email", "name", "created_at", "version", "deleted_at") VALUES($1, $2, $3, $4, $5) ON CONFLICT ("email") DO UPDATE SET "name"=EXCLUDED."name", "version"=EXCLUDED."version"`,
+	}, "e", "n", now, int64(3), NullTime{})
+
+	b := Create("users").Set("email", "e").OnConflict("email").WithDialect(SQLServer)
+	if q, _ := b.QueryWithArgs(); q != "" || b.GetError() == nil {
+		t.Errorf("SQL Server upsert rendered %q, %v", q, b.GetError())
+	}
+}
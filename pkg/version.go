@@ -0,0 +1,34 @@
+package gql
+
+import "reflect"
+
+// lockVersion returns the version field of a struct bound to an update, which then only applies to
+// the row still at that version and increments it.
+func (b *QueryBuilder) lockVersion() (*FieldInfo, reflect.Value, bool) {
+	if b.typ != SqlTypUpdate || b.obj == nil || b.model == nil || b.model.Version == nil {
+		return nil, reflect.Value{}, false
+	}
+	vf := reflect.Indirect(reflect.ValueOf(b.obj).Elem())
+	if vf.Kind() != reflect.Struct {
+		return nil, reflect.Value{}, false
+	}
+	value, ok := fieldOf(vf, b.model.Version.Index, false)
+	return b.model.Version, value, ok
+}
+
+func (b *QueryBuilder) bumpVersion() error {
+	_, version, ok := b.lockVersion()
+	if !ok {
+		return nil
+	}
+	if b.rowsAffected == 0 {
+		return ErrStaleObject
+	}
+	switch version.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		version.SetInt(version.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		version.SetUint(version.Uint() + 1)
+	}
+	return nil
+}
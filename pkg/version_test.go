@@ -0,0 +1,31 @@
+package gql
+
+import "testing"
+
+type versioned struct {
+	ID      int64  `gql:"id"`
+	Name    string `gql:"name"`
+	Version int64  `gql:"version,version"`
+}
+
+func (versioned) TableName() string { return "items" }
+
+func TestVersion(t *testing.T) {
+	checkSQL(t, func() Builder { return Update("").Bind(&versioned{ID: 1, Name: "n", Version: 4}).Where("id", 1) }, golden{
+		"mysql":     "UPDATE `items` SET `name`=?, `version`=`version` + 1 WHERE (`id` = ?) AND `version` = ?",
+		"postgres":  `UPDATE "items" SET "name"=$1, "version"="version" + 1 WHERE ("id" = $2) AND "version" = $3`,
+		"sqlserver": "UPDATE [items] SET [name]=@p1, [version]=[version] + 1 WHERE ([id] = @p2) AND [version] = @p3",
+	}, "n", 1, int64(4))
+}
+
+func TestBumpVersion(t *testing.T) {
+	v := versioned{ID: 1, Version: 4}
+	b := Update("").Bind(&v).Where("id", 1).(*QueryBuilder)
+	if err := b.bumpVersion(); err != ErrStaleObject {
+		t.Fatalf("no affected rows: %v", err)
+	}
+	b.rowsAffected = 1
+	if err := b.bumpVersion(); err != nil || v.Version != 5 {
+		t.Fatalf("%v, version %d", err, v.Version)
+	}
+}